@@ -0,0 +1,80 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterSyncedSecretSpec defines the desired state of ClusterSyncedSecret
+type ClusterSyncedSecretSpec struct {
+	// NamespaceSelector selects the namespaces the Secret is created in
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+
+	// The Secret created in every selected namespace, as for a SyncedSecret.
+	// The namespace set in the secret metadata is ignored.
+	SyncedSecretSpec `json:",inline"`
+}
+
+// ClusterSyncedSecretStatus defines the observed state of ClusterSyncedSecret
+type ClusterSyncedSecretStatus struct {
+	// Namespaces the Secret was successfully synced to
+	// +optional
+	SyncedNamespaces []string `json:"syncedNamespaces,omitempty"`
+
+	// Namespaces selected by the NamespaceSelector the Secret failed to be synced to
+	// +optional
+	FailedNamespaces []string `json:"failedNamespaces,omitempty"`
+
+	// ObservedGeneration is the generation of the ClusterSyncedSecret that was last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest observations of the ClusterSyncedSecret's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterSyncedSecret is the Schema for the ClusterSyncedSecrets API
+type ClusterSyncedSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterSyncedSecretSpec   `json:"spec,omitempty"`
+	Status ClusterSyncedSecretStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterSyncedSecretList contains a list of ClusterSyncedSecret
+type ClusterSyncedSecretList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterSyncedSecret `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterSyncedSecret{}, &ClusterSyncedSecretList{})
+}
@@ -0,0 +1,52 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretTemplateSpec defines the desired state of SecretTemplate
+type SecretTemplateSpec struct {
+	// Template is a Go template, referenced by the name of the SecretTemplate from a templateRef or with
+	// {{ include "name" . }}. Parameters passed by a templateRef are available as .Params.
+	Template string `json:"template"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// SecretTemplate is the Schema for the SecretTemplates API, a template shared by SyncedSecrets
+type SecretTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SecretTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SecretTemplateList contains a list of SecretTemplate
+type SecretTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SecretTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SecretTemplate{}, &SecretTemplateList{})
+}
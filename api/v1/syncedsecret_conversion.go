@@ -0,0 +1,299 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	secretsv2 "github.com/contentful-labs/kube-secret-syncer/api/v2"
+)
+
+var _ conversion.Convertible = &SyncedSecret{}
+
+// annotationV1Spec holds the v1 spec of a SyncedSecret stored as v2, when the v2 spec can not represent all of it:
+// an empty string rather than an unset field, a nil data field or a creationTimestamp in the secret metadata
+const annotationV1Spec = "secrets.contentful.com/v1-spec"
+
+// ConvertTo converts this SyncedSecret to the v2 hub version
+func (src *SyncedSecret) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*secretsv2.SyncedSecret)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	delete(dst.Annotations, annotationV1Spec)
+
+	dst.Spec = specToV2(&src.Spec)
+	if !equality.Semantic.DeepEqual(specFromV2(&dst.Spec), src.Spec) {
+		v1Spec, err := json.Marshal(src.Spec)
+		if err != nil {
+			return errors.Wrap(err, "failed encoding the v1 spec")
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[annotationV1Spec] = string(v1Spec)
+	}
+
+	dst.Status = secretsv2.SyncedSecretStatus{
+		SecretHash:             src.Status.SecretHash,
+		CurrentSecretName:      src.Status.CurrentSecretName,
+		CurrentSecretNamespace: src.Status.CurrentSecretNamespace,
+		CurrentVersionID:       src.Status.CurrentVersionID,
+		SourceVersions:         src.Status.SourceVersions,
+		ObservedGeneration:     src.Status.ObservedGeneration,
+		LastSyncTime:           src.Status.LastSyncTime,
+		Conditions:             src.Status.Conditions,
+	}
+
+	return nil
+}
+
+// ConvertFrom converts a SyncedSecret from the v2 hub version to this version. The v1 spec saved when converting to
+// v2 is restored, unless the v2 spec was changed since.
+func (dst *SyncedSecret) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*secretsv2.SyncedSecret)
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec = specFromV2(&src.Spec)
+	if v1Spec, ok := dst.Annotations[annotationV1Spec]; ok {
+		delete(dst.Annotations, annotationV1Spec)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+		var spec SyncedSecretSpec
+		if err := json.Unmarshal([]byte(v1Spec), &spec); err == nil && equality.Semantic.DeepEqual(specToV2(&spec), src.Spec) {
+			dst.Spec = spec
+		}
+	}
+
+	dst.Status = SyncedSecretStatus{
+		CurrentVersionID:       src.Status.CurrentVersionID,
+		SecretHash:             src.Status.SecretHash,
+		CurrentSecretName:      src.Status.CurrentSecretName,
+		CurrentSecretNamespace: src.Status.CurrentSecretNamespace,
+		SourceVersions:         src.Status.SourceVersions,
+		ObservedGeneration:     src.Status.ObservedGeneration,
+		LastSyncTime:           src.Status.LastSyncTime,
+		Conditions:             src.Status.Conditions,
+	}
+
+	return nil
+}
+
+// specToV2 converts a v1 spec to v2
+func specToV2(src *SyncedSecretSpec) secretsv2.SyncedSecretSpec {
+	dst := secretsv2.SyncedSecretSpec{
+		Provider: secretsv2.Provider{
+			AWS: &secretsv2.AWSProvider{
+				Role:      stringValue(src.IAMRole),
+				AccountID: stringValue(src.AWSAccountID),
+			},
+		},
+		Target: secretsv2.Target{
+			Name:                  src.SecretMetadata.Name,
+			Namespace:             src.SecretMetadata.Namespace,
+			Labels:                src.SecretMetadata.Labels,
+			Annotations:           src.SecretMetadata.Annotations,
+			Type:                  src.SecretMetadata.Type,
+			PropagateTags:         propagateTagsToV2(src.SecretMetadata.PropagateTags),
+			TemplateMetadata:      src.SecretMetadata.TemplateMetadata,
+			CreationPolicy:        secretsv2.CreationPolicy(src.CreationPolicy),
+			DeletionPolicy:        secretsv2.DeletionPolicy(src.DeletionPolicy),
+			Immutable:             src.Immutable,
+			ImmutableHistoryLimit: src.ImmutableHistoryLimit,
+		},
+		RefreshInterval: src.RefreshInterval,
+		Suspend:         src.Suspend,
+	}
+
+	for _, field := range src.Data {
+		if field == nil {
+			continue
+		}
+		dstField := secretsv2.SecretField{
+			Name:  stringValue(field.Name),
+			Value: field.Value,
+		}
+		if field.ValueFrom != nil {
+			dstField.ValueFrom = &secretsv2.ValueFrom{
+				SecretRef:        secretRefToV2(field.ValueFrom.SecretRef),
+				Template:         stringValue(field.ValueFrom.Template),
+				TemplateRef:      (*secretsv2.TemplateRef)(field.ValueFrom.TemplateRef),
+				DecodingStrategy: secretsv2.DecodingStrategy(field.ValueFrom.DecodingStrategy),
+			}
+			if ref := field.ValueFrom.SecretKeyRef; ref != nil {
+				dstField.ValueFrom.SecretKeyRef = &secretsv2.SecretKeyRef{
+					Name:         stringValue(ref.Name),
+					Key:          stringValue(ref.Key),
+					Optional:     ref.Optional,
+					VersionStage: ref.VersionStage,
+					VersionID:    ref.VersionID,
+				}
+			}
+		}
+		dst.Data = append(dst.Data, dstField)
+	}
+
+	if src.DataFrom != nil {
+		dst.DataFrom = &secretsv2.DataFrom{
+			DecodingStrategy: secretsv2.DecodingStrategy(src.DataFrom.DecodingStrategy),
+			Flatten:          src.DataFrom.Flatten,
+		}
+		if ref := secretRefToV2(src.DataFrom.SecretRef); ref != nil {
+			dst.DataFrom.SecretRef = *ref
+		}
+	}
+
+	if src.TemplateFrom != nil {
+		dst.TemplateFrom = &secretsv2.TemplateFrom{
+			Template:         stringValue(src.TemplateFrom.Template),
+			TemplateRef:      (*secretsv2.TemplateRef)(src.TemplateFrom.TemplateRef),
+			DecodingStrategy: secretsv2.DecodingStrategy(src.TemplateFrom.DecodingStrategy),
+		}
+	}
+
+	return dst
+}
+
+// specFromV2 converts a v2 spec to v1
+func specFromV2(src *secretsv2.SyncedSecretSpec) SyncedSecretSpec {
+	dst := SyncedSecretSpec{
+		SecretMetadata: SecretMetadata{
+			Name:             src.Target.Name,
+			Namespace:        src.Target.Namespace,
+			Labels:           src.Target.Labels,
+			Annotations:      src.Target.Annotations,
+			Type:             src.Target.Type,
+			PropagateTags:    propagateTagsFromV2(src.Target.PropagateTags),
+			TemplateMetadata: src.Target.TemplateMetadata,
+		},
+		CreationPolicy:        CreationPolicy(src.Target.CreationPolicy),
+		DeletionPolicy:        DeletionPolicy(src.Target.DeletionPolicy),
+		Immutable:             src.Target.Immutable,
+		ImmutableHistoryLimit: src.Target.ImmutableHistoryLimit,
+		RefreshInterval:       src.RefreshInterval,
+		Suspend:               src.Suspend,
+	}
+	if src.Provider.AWS != nil {
+		dst.IAMRole = optionalString(src.Provider.AWS.Role)
+		dst.AWSAccountID = optionalString(src.Provider.AWS.AccountID)
+	}
+
+	for _, field := range src.Data {
+		dstField := &SecretField{
+			Name:  optionalString(field.Name),
+			Value: field.Value,
+		}
+		if field.ValueFrom != nil {
+			dstField.ValueFrom = &ValueFrom{
+				SecretRef:        secretRefFromV2(field.ValueFrom.SecretRef),
+				Template:         optionalString(field.ValueFrom.Template),
+				TemplateRef:      (*TemplateRef)(field.ValueFrom.TemplateRef),
+				DecodingStrategy: DecodingStrategy(field.ValueFrom.DecodingStrategy),
+			}
+			if ref := field.ValueFrom.SecretKeyRef; ref != nil {
+				dstField.ValueFrom.SecretKeyRef = &SecretKeyRef{
+					Name:         optionalString(ref.Name),
+					Key:          optionalString(ref.Key),
+					Optional:     ref.Optional,
+					VersionStage: ref.VersionStage,
+					VersionID:    ref.VersionID,
+				}
+			}
+		}
+		dst.Data = append(dst.Data, dstField)
+	}
+
+	if src.DataFrom != nil {
+		dst.DataFrom = &DataFrom{
+			SecretRef:        secretRefFromV2(&src.DataFrom.SecretRef),
+			DecodingStrategy: DecodingStrategy(src.DataFrom.DecodingStrategy),
+			Flatten:          src.DataFrom.Flatten,
+		}
+	}
+
+	if src.TemplateFrom != nil {
+		dst.TemplateFrom = &TemplateFrom{
+			Template:         optionalString(src.TemplateFrom.Template),
+			TemplateRef:      (*TemplateRef)(src.TemplateFrom.TemplateRef),
+			DecodingStrategy: DecodingStrategy(src.TemplateFrom.DecodingStrategy),
+		}
+	}
+
+	return dst
+}
+
+func propagateTagsToV2(propagate *PropagateTags) *secretsv2.PropagateTags {
+	if propagate == nil {
+		return nil
+	}
+	return &secretsv2.PropagateTags{
+		Tags:   propagate.Tags,
+		Target: secretsv2.TagTarget(propagate.Target),
+		Prefix: propagate.Prefix,
+	}
+}
+
+func propagateTagsFromV2(propagate *secretsv2.PropagateTags) *PropagateTags {
+	if propagate == nil {
+		return nil
+	}
+	return &PropagateTags{
+		Tags:   propagate.Tags,
+		Target: TagTarget(propagate.Target),
+		Prefix: propagate.Prefix,
+	}
+}
+
+func secretRefToV2(ref *SecretRef) *secretsv2.SecretRef {
+	if ref == nil {
+		return nil
+	}
+	return &secretsv2.SecretRef{
+		Name:         stringValue(ref.Name),
+		VersionStage: ref.VersionStage,
+		VersionID:    ref.VersionID,
+	}
+}
+
+// secretRefFromV2 returns nil for an empty reference, as the secretRef of a v2 dataFrom can not be omitted
+func secretRefFromV2(ref *secretsv2.SecretRef) *SecretRef {
+	if ref == nil || *ref == (secretsv2.SecretRef{}) {
+		return nil
+	}
+	return &SecretRef{
+		Name:         optionalString(ref.Name),
+		VersionStage: ref.VersionStage,
+		VersionID:    ref.VersionID,
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// optionalString returns nil for empty strings, which v2 does not distinguish from unset ones
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	fuzz "github.com/google/gofuzz"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretsv2 "github.com/contentful-labs/kube-secret-syncer/api/v2"
+)
+
+func _s(A string) *string {
+	return &A
+}
+
+func TestSyncedSecretConversion(t *testing.T) {
+	historyLimit := int32(2)
+	lastSync := metav1.NewTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	testCases := []struct {
+		name string
+		v1   SyncedSecret
+	}{
+		{
+			name: "IAMRole and data",
+			v1: SyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+				Spec: SyncedSecretSpec{
+					SecretMetadata: SecretMetadata{
+						Name:          "demo-secret",
+						Namespace:     "other",
+						Labels:        map[string]string{"app": "demo"},
+						Annotations:   map[string]string{"owner": "team"},
+						Type:          corev1.SecretTypeTLS,
+						PropagateTags: &PropagateTags{Tags: []string{"owner"}, Target: TagTargetLabels, Prefix: "aws.contentful.com"},
+					},
+					IAMRole: _s("iam_role"),
+					Data: []*SecretField{
+						{Name: _s("DB_NAME"), Value: _s("db")},
+						{Name: _s("EMPTY"), Value: _s("")},
+						{Name: _s("DB_PASS"), ValueFrom: &ValueFrom{SecretKeyRef: &SecretKeyRef{Name: _s("db"), Key: _s("password"), Optional: true, VersionStage: "AWSPREVIOUS"}}},
+						{Name: _s("CERT"), ValueFrom: &ValueFrom{SecretRef: &SecretRef{Name: _s("cert"), VersionID: "1"}, DecodingStrategy: DecodingStrategyBase64}},
+						{Name: _s("URL"), ValueFrom: &ValueFrom{Template: _s(`{{ getSecretValue "db" }}`)}},
+						{Name: _s("DSN"), ValueFrom: &ValueFrom{TemplateRef: &TemplateRef{Name: "dsn", Parameters: map[string]string{"db": "main"}}}},
+					},
+					RefreshInterval:       &metav1.Duration{Duration: time.Minute},
+					Suspend:               true,
+					CreationPolicy:        CreationPolicyOwner,
+					Immutable:             true,
+					ImmutableHistoryLimit: &historyLimit,
+					DeletionPolicy:        DeletionPolicyRetain,
+				},
+				Status: SyncedSecretStatus{
+					CurrentVersionID:   "1",
+					SecretHash:         "hash",
+					CurrentSecretName:  "demo-secret-hash",
+					SourceVersions:     map[string]string{"db": "1"},
+					ObservedGeneration: 3,
+					LastSyncTime:       &lastSync,
+					Conditions:         []metav1.Condition{{Type: ConditionTypeReady, Status: metav1.ConditionTrue, Reason: ReasonSynced}},
+				},
+			},
+		},
+		{
+			name: "AWSAccountID, dataFrom and templateFrom",
+			v1: SyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+				Spec: SyncedSecretSpec{
+					AWSAccountID: _s("123456789012"),
+					DataFrom:     &DataFrom{SecretRef: &SecretRef{Name: _s("db")}, DecodingStrategy: DecodingStrategyHex, Flatten: true},
+					TemplateFrom: &TemplateFrom{Template: _s(`data: {a: b}`), DecodingStrategy: DecodingStrategyBase64},
+				},
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		v2 := &secretsv2.SyncedSecret{}
+		if err := test.v1.ConvertTo(v2); err != nil {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+
+		got := SyncedSecret{}
+		if err := got.ConvertFrom(v2); err != nil {
+			t.Fatalf("%s: unexpected error %v", test.name, err)
+		}
+		if !reflect.DeepEqual(got, test.v1) {
+			t.Errorf("%s: wanted %+v after converting to v2 and back, got %+v", test.name, test.v1, got)
+		}
+	}
+}
+
+func TestSyncedSecretConvertTo(t *testing.T) {
+	v1 := &SyncedSecret{
+		Spec: SyncedSecretSpec{
+			SecretMetadata: SecretMetadata{Name: "demo-secret"},
+			IAMRole:        _s("iam_role"),
+			Data:           []*SecretField{{Name: _s("DB_NAME"), ValueFrom: &ValueFrom{SecretKeyRef: &SecretKeyRef{Name: _s("db"), Key: _s("name")}}}},
+			CreationPolicy: CreationPolicyMerge,
+		},
+	}
+
+	got := &secretsv2.SyncedSecret{}
+	if err := v1.ConvertTo(got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := secretsv2.SyncedSecretSpec{
+		Provider: secretsv2.Provider{AWS: &secretsv2.AWSProvider{Role: "iam_role"}},
+		Target:   secretsv2.Target{Name: "demo-secret", CreationPolicy: secretsv2.CreationPolicyMerge},
+		Data:     []secretsv2.SecretField{{Name: "DB_NAME", ValueFrom: &secretsv2.ValueFrom{SecretKeyRef: &secretsv2.SecretKeyRef{Name: "db", Key: "name"}}}},
+	}
+	if !reflect.DeepEqual(got.Spec, want) {
+		t.Errorf("wanted %+v, got %+v", want, got.Spec)
+	}
+}
+
+func TestSyncedSecretConversionRoundTrip(t *testing.T) {
+	f := fuzz.New().NilChance(0.3)
+	for i := 0; i < 1000; i++ {
+		original := SyncedSecret{}
+		f.Fuzz(&original)
+		// the kind and API version are set by the conversion webhook, not by the conversion functions
+		original.TypeMeta = metav1.TypeMeta{}
+
+		v2 := &secretsv2.SyncedSecret{}
+		if err := original.DeepCopy().ConvertTo(v2); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		got := SyncedSecret{}
+		if err := got.ConvertFrom(v2); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if !equality.Semantic.DeepEqual(got, original) {
+			t.Fatalf("wanted %+v after converting to v2 and back, got %+v", original.Spec, got.Spec)
+		}
+	}
+}
+
+func TestSyncedSecretConversionAfterV2Update(t *testing.T) {
+	v1 := &SyncedSecret{
+		Spec: SyncedSecretSpec{
+			SecretMetadata: SecretMetadata{Name: "demo-secret", CreationTimestamp: metav1.Unix(1, 0)},
+			IAMRole:        _s("iam_role"),
+			Data:           []*SecretField{nil, {Name: _s("DB_NAME"), Value: _s("db")}},
+		},
+	}
+
+	v2 := &secretsv2.SyncedSecret{}
+	if err := v1.ConvertTo(v2); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v2.Annotations[annotationV1Spec]; !ok {
+		t.Fatalf("expected the v1 spec to be saved in %s", annotationV1Spec)
+	}
+
+	v2.Spec.Target.Name = "other-secret"
+	got := &SyncedSecret{}
+	if err := got.ConvertFrom(v2); err != nil {
+		t.Fatal(err)
+	}
+	want := SyncedSecretSpec{
+		SecretMetadata: SecretMetadata{Name: "other-secret"},
+		IAMRole:        _s("iam_role"),
+		Data:           []*SecretField{{Name: _s("DB_NAME"), Value: _s("db")}},
+	}
+	if !reflect.DeepEqual(got.Spec, want) {
+		t.Errorf("wanted %+v, got %+v", want, got.Spec)
+	}
+	if got.Annotations != nil {
+		t.Errorf("expected %s to be removed, got %v", annotationV1Spec, got.Annotations)
+	}
+}
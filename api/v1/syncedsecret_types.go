@@ -16,23 +16,130 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// +kubebuilder:validation:XValidation:rule="!(has(self.versionStage) && has(self.versionId))",message="only one of versionStage and versionId can be set"
 type SecretRef struct {
 	Name *string `json:"name"`
+
+	// VersionStage of the secret to use, e.g. AWSPREVIOUS. Defaults to AWSCURRENT.
+	// +optional
+	VersionStage string `json:"versionStage,omitempty"`
+
+	// VersionID of the secret to use, instead of the version with stage AWSCURRENT
+	// +optional
+	VersionID string `json:"versionId,omitempty"`
 }
 
+// DecodingStrategy is applied to values read from Secrets Manager before they are written to the Secret
+// +kubebuilder:validation:Enum=None;Base64;Base64URL;Hex;Gzip
+type DecodingStrategy string
+
+const (
+	DecodingStrategyNone      DecodingStrategy = "None"
+	DecodingStrategyBase64    DecodingStrategy = "Base64"
+	DecodingStrategyBase64URL DecodingStrategy = "Base64URL"
+	DecodingStrategyHex       DecodingStrategy = "Hex"
+	DecodingStrategyGzip      DecodingStrategy = "Gzip"
+)
+
+// CreationPolicy defines how the generated Secret is written
+// +kubebuilder:validation:Enum=Owner;Merge;Orphan;None
+type CreationPolicy string
+
+const (
+	// CreationPolicyOwner creates the Secret and manages all of it. An existing Secret is only taken over if it is
+	// annotated as managed by the SyncedSecret.
+	CreationPolicyOwner CreationPolicy = "Owner"
+	// CreationPolicyMerge writes the synced keys into an existing Secret, keeping its other keys, labels and
+	// annotations. The Secret is not created if it does not exist.
+	CreationPolicyMerge CreationPolicy = "Merge"
+	// CreationPolicyOrphan creates and updates the Secret without marking it as managed, an existing Secret is only
+	// overwritten if it was written by the last sync
+	CreationPolicyOrphan CreationPolicy = "Orphan"
+	// CreationPolicyNone does not write the Secret
+	CreationPolicyNone CreationPolicy = "None"
+)
+
+// TagTarget defines where propagated tags are written on the generated Secret
+// +kubebuilder:validation:Enum=Labels;Annotations
+type TagTarget string
+
+const (
+	// TagTargetLabels writes tags as labels, rewriting their values to valid label values
+	TagTargetLabels TagTarget = "Labels"
+	// TagTargetAnnotations writes tags as annotations
+	TagTargetAnnotations TagTarget = "Annotations"
+)
+
+// PropagateTags copies tags of the secrets read from Secrets Manager onto the generated Secret. Tag keys are rewritten
+// to valid label keys, e.g. "Cost Centre" becomes Cost-Centre. Tags whose value differs between the secrets read are
+// not copied.
+type PropagateTags struct {
+	// Tags lists the keys of the tags copied, other tags are ignored
+	// +kubebuilder:validation:MinItems=1
+	Tags []string `json:"tags"`
+
+	// Target the tags are written to: Labels or Annotations. Defaults to Annotations.
+	// +optional
+	Target TagTarget `json:"target,omitempty"`
+
+	// Prefix of the written keys, e.g. aws.contentful.com for aws.contentful.com/owner. Defaults to no prefix.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// DeletionPolicy defines what happens to the generated Secret when it stops being managed
+// +kubebuilder:validation:Enum=Delete;Retain;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the Secret
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain keeps the Secret as it is
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyOrphan keeps the Secret, but removes the annotation marking it as managed, so that it can be
+	// adopted by another SyncedSecret
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
 type DataFrom struct {
 	SecretRef *SecretRef `json:"secretRef,omitempty"`
+
+	// DecodingStrategy applied to every value of the secret. Defaults to None.
+	// +optional
+	DecodingStrategy DecodingStrategy `json:"decodingStrategy,omitempty"`
+
+	// Flatten writes nested objects as one key per value, joining the keys with dots: {"db": {"user": "foo"}}
+	// becomes the key db.user. Nested objects are otherwise written as JSON.
+	// +optional
+	Flatten bool `json:"flatten,omitempty"`
 }
 
+// +kubebuilder:validation:XValidation:rule="!(has(self.versionStage) && has(self.versionId))",message="only one of versionStage and versionId can be set"
 type SecretKeyRef struct {
 	Name *string `json:"name"`
-	Key  *string `json:"key"`
+
+	// Key in the JSON secret. If the secret has no top-level key with that name, it is read as a path to a nested
+	// value, e.g. db.primary.password, db.replicas[0].host or $.db["key.with.dots"]
+	Key *string `json:"key"`
+
+	// Optional skips the field when the key does not exist in the secret, instead of failing the sync
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+
+	// VersionStage of the secret to use, e.g. AWSPREVIOUS. Defaults to AWSCURRENT.
+	// +optional
+	VersionStage string `json:"versionStage,omitempty"`
+
+	// VersionID of the secret to use, instead of the version with stage AWSCURRENT
+	// +optional
+	VersionID string `json:"versionId,omitempty"`
 }
 
 type ValueFrom struct {
@@ -47,6 +154,40 @@ type ValueFrom struct {
 	// Template
 	// +optional
 	Template *string `json:"template,omitempty"`
+
+	// TemplateRef renders the value with a SecretTemplate
+	// +optional
+	TemplateRef *TemplateRef `json:"templateRef,omitempty"`
+
+	// DecodingStrategy applied to the value before it is written. Defaults to None.
+	// +optional
+	DecodingStrategy DecodingStrategy `json:"decodingStrategy,omitempty"`
+}
+
+// TemplateRef references a SecretTemplate
+type TemplateRef struct {
+	// Name of the SecretTemplate
+	Name string `json:"name"`
+
+	// Parameters passed to the template, available as .Params
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// TemplateFrom renders a YAML or JSON object with the optional keys data, labels and annotations, each a map of
+// strings. Data values that are not strings are written as JSON.
+type TemplateFrom struct {
+	// Template is a Go template
+	// +optional
+	Template *string `json:"template,omitempty"`
+
+	// TemplateRef renders a SecretTemplate
+	// +optional
+	TemplateRef *TemplateRef `json:"templateRef,omitempty"`
+
+	// DecodingStrategy applied to the data values before they are written. Defaults to None.
+	// +optional
+	DecodingStrategy DecodingStrategy `json:"decodingStrategy,omitempty"`
 }
 
 type SecretField struct {
@@ -67,6 +208,21 @@ type SecretMetadata struct {
 	Labels            map[string]string `json:"labels,omitempty"`
 	Annotations       map[string]string `json:"annotations,omitempty"`
 	CreationTimestamp metav1.Time       `json:"creationTimestamp,omitempty"`
+
+	// Type of the generated Secret, e.g. kubernetes.io/tls or kubernetes.io/dockerconfigjson.
+	// Defaults to Opaque.
+	// +optional
+	Type corev1.SecretType `json:"type,omitempty"`
+
+	// PropagateTags copies tags of the secrets read from Secrets Manager onto the labels or annotations of the
+	// Secret. Labels and annotations set above take precedence over them.
+	// +optional
+	PropagateTags *PropagateTags `json:"propagateTags,omitempty"`
+
+	// TemplateMetadata renders the values of the labels and annotations above as templates, with the same functions
+	// and data as templated fields. They are written as they are otherwise.
+	// +optional
+	TemplateMetadata bool `json:"templateMetadata,omitempty"`
 }
 
 // SyncedSecretSpec defines the desired state of SyncedSecret
@@ -88,9 +244,42 @@ type SyncedSecretSpec struct {
 	// +optional
 	DataFrom *DataFrom `json:"dataFrom,omitempty"`
 
+	// TemplateFrom renders a template into the data, labels and annotations of the Secret. Its keys override the
+	// keys of dataFrom, and are overridden by the fields of data and the secret metadata.
+	// +optional
+	TemplateFrom *TemplateFrom `json:"templateFrom,omitempty"`
+
 	// AWSAccountID
 	// +optional
 	AWSAccountID *string `json:"AWSAccountID,omitempty"`
+
+	// RefreshInterval is how often the Secret is refreshed from Secrets Manager, e.g. 1m. Intervals shorter than
+	// the minimum configured for the cluster are raised to it. Defaults to the global sync interval.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+
+	// Suspend stops syncing the Secret, leaving it as it is until Suspend is unset
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// CreationPolicy defines how the generated Secret is written: Owner, Merge, Orphan or None. Defaults to Owner.
+	// +optional
+	CreationPolicy CreationPolicy `json:"creationPolicy,omitempty"`
+
+	// Immutable writes every version of the Secret as a new immutable Secret named after the secret name and the
+	// hash of its data, e.g. demo-service-secret-1a2b3c4d5e. Requires the Owner creation policy.
+	// +optional
+	Immutable bool `json:"immutable,omitempty"`
+
+	// ImmutableHistoryLimit is the number of previous immutable Secrets kept. Defaults to 3.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ImmutableHistoryLimit *int32 `json:"immutableHistoryLimit,omitempty"`
+
+	// DeletionPolicy defines what happens to the generated Secret when the SyncedSecret is deleted: Delete, Retain
+	// or Orphan. Only applies to Secrets created with the Owner creation policy. Defaults to Delete.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
 }
 
 // SyncedSecretStatus defines the observed state of SyncedSecret
@@ -99,14 +288,77 @@ type SyncedSecretStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
 	// this is the version of the secret that is present in k8s secret this should be coming from the local cache
+	// only set when the SyncedSecret references a single secret, see SourceVersions otherwise
 	CurrentVersionID string `json:"currentVersionID"`
 
 	// hash(secret.data) that was generated, used for checking of a Secret has diverged and if it needs reconciling
 	SecretHash string `json:"generatedSecretHash,omitempty"`
+
+	// CurrentSecretName is the name of the generated Secret, which changes with every version of immutable Secrets
+	// +optional
+	CurrentSecretName string `json:"currentSecretName,omitempty"`
+
+	// CurrentSecretNamespace is the namespace of the generated Secret
+	// +optional
+	CurrentSecretNamespace string `json:"currentSecretNamespace,omitempty"`
+
+	// SourceVersions maps the ID of every secret in Secrets Manager used to generate the Secret to its VersionId
+	// +optional
+	SourceVersions map[string]string `json:"sourceVersions,omitempty"`
+
+	// ObservedGeneration is the generation of the SyncedSecret that was last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSyncTime is the last time the Secret was successfully synced
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Conditions represent the latest observations of the SyncedSecret's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+const (
+	// ConditionTypeReady is true when the Secret was successfully synced
+	ConditionTypeReady = "Ready"
+
+	// ReasonSynced is set on the Ready condition when the Secret was successfully synced
+	ReasonSynced = "Synced"
+	// ReasonRoleNotAllowed is set on the Ready condition when the IAM role is not allowed in the namespace
+	ReasonRoleNotAllowed = "RoleNotAllowed"
+	// ReasonNamespaceNotAllowed is set on the Ready condition when a secret is not allowed in the namespace
+	ReasonNamespaceNotAllowed = "NamespaceNotAllowed"
+	// ReasonSourceNotFound is set on the Ready condition when a secret can not be found in Secrets Manager
+	ReasonSourceNotFound = "SourceNotFound"
+	// ReasonKeyNotFound is set on the Ready condition when a key referenced by a secretKeyRef does not exist
+	ReasonKeyNotFound = "KeyNotFound"
+	// ReasonDecodingFailed is set on the Ready condition when a value can not be decoded with its decoding strategy
+	ReasonDecodingFailed = "DecodingFailed"
+	// ReasonSecretConflict is set on the Ready condition when the Secret exists and is not managed by the SyncedSecret
+	ReasonSecretConflict = "SecretConflict"
+	// ReasonSecretTypeMismatch is set on the Ready condition when the Secret exists with another type, and is not
+	// owned by the SyncedSecret so it can not be recreated with the new type
+	ReasonSecretTypeMismatch = "SecretTypeMismatch"
+	// ReasonTemplateError is set on the Ready condition when a template fails to parse or execute
+	ReasonTemplateError = "TemplateError"
+	// ReasonTemplateLimitExceeded is set on the Ready condition when a template exceeds its timeout, secret reads or
+	// output size
+	ReasonTemplateLimitExceeded = "TemplateLimitExceeded"
+	// ReasonSuspended is set on the Ready condition while syncing is suspended
+	ReasonSuspended = "Suspended"
+	// ReasonSyncFailed is set on the Ready condition for any other failure
+	ReasonSyncFailed = "SyncFailed"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Last Sync",type=date,JSONPath=`.status.lastSyncTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // SyncedSecret is the Schema for the SyncedSecrets API
 type SyncedSecret struct {
@@ -0,0 +1,392 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DecodingStrategy is applied to values read from the provider before they are written to the Secret
+// +kubebuilder:validation:Enum=None;Base64;Base64URL;Hex;Gzip
+type DecodingStrategy string
+
+const (
+	DecodingStrategyNone      DecodingStrategy = "None"
+	DecodingStrategyBase64    DecodingStrategy = "Base64"
+	DecodingStrategyBase64URL DecodingStrategy = "Base64URL"
+	DecodingStrategyHex       DecodingStrategy = "Hex"
+	DecodingStrategyGzip      DecodingStrategy = "Gzip"
+)
+
+// CreationPolicy defines how the generated Secret is written
+// +kubebuilder:validation:Enum=Owner;Merge;Orphan;None
+type CreationPolicy string
+
+const (
+	// CreationPolicyOwner creates the Secret and manages all of it. An existing Secret is only taken over if it is
+	// annotated as managed by the SyncedSecret.
+	CreationPolicyOwner CreationPolicy = "Owner"
+	// CreationPolicyMerge writes the synced keys into an existing Secret, keeping its other keys, labels and
+	// annotations. The Secret is not created if it does not exist.
+	CreationPolicyMerge CreationPolicy = "Merge"
+	// CreationPolicyOrphan creates and updates the Secret without marking it as managed, an existing Secret is only
+	// overwritten if it was written by the last sync
+	CreationPolicyOrphan CreationPolicy = "Orphan"
+	// CreationPolicyNone does not write the Secret
+	CreationPolicyNone CreationPolicy = "None"
+)
+
+// DeletionPolicy defines what happens to the generated Secret when it stops being managed
+// +kubebuilder:validation:Enum=Delete;Retain;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyDelete deletes the Secret
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	// DeletionPolicyRetain keeps the Secret as it is
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyOrphan keeps the Secret, but removes the annotation marking it as managed, so that it can be
+	// adopted by another SyncedSecret
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// TagTarget defines where propagated tags are written on the generated Secret
+// +kubebuilder:validation:Enum=Labels;Annotations
+type TagTarget string
+
+const (
+	// TagTargetLabels writes tags as labels, rewriting their values to valid label values
+	TagTargetLabels TagTarget = "Labels"
+	// TagTargetAnnotations writes tags as annotations
+	TagTargetAnnotations TagTarget = "Annotations"
+)
+
+// PropagateTags copies tags of the secrets read from the provider onto the generated Secret. Tag keys are rewritten
+// to valid label keys, e.g. "Cost Centre" becomes Cost-Centre. Tags whose value differs between the secrets read are
+// not copied.
+type PropagateTags struct {
+	// Tags lists the keys of the tags copied, other tags are ignored
+	// +kubebuilder:validation:MinItems=1
+	Tags []string `json:"tags"`
+
+	// Target the tags are written to: Labels or Annotations. Defaults to Annotations.
+	// +optional
+	Target TagTarget `json:"target,omitempty"`
+
+	// Prefix of the written keys, e.g. aws.contentful.com for aws.contentful.com/owner. Defaults to no prefix.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// Provider defines where secrets are read from. Exactly one provider must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.aws)",message="a provider must be set"
+type Provider struct {
+	// AWS reads secrets from AWS Secrets Manager
+	// +optional
+	AWS *AWSProvider `json:"aws,omitempty"`
+}
+
+// AWSProvider reads secrets from AWS Secrets Manager
+type AWSProvider struct {
+	// Role is the name or ARN of the IAM role assumed to read secrets. It must be allowed in the namespace of the
+	// Secret.
+	// +optional
+	Role string `json:"role,omitempty"`
+
+	// AccountID reads secrets from another AWS account, through the secret-syncer role of that account. Takes
+	// precedence over Role.
+	// +optional
+	AccountID string `json:"accountID,omitempty"`
+}
+
+// SecretRef references a secret of the provider
+// +kubebuilder:validation:XValidation:rule="!(has(self.versionStage) && has(self.versionId))",message="only one of versionStage and versionId can be set"
+type SecretRef struct {
+	// Name of the secret
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// VersionStage of the secret to use, e.g. AWSPREVIOUS. Defaults to AWSCURRENT.
+	// +optional
+	VersionStage string `json:"versionStage,omitempty"`
+
+	// VersionID of the secret to use, instead of the version with stage AWSCURRENT
+	// +optional
+	VersionID string `json:"versionId,omitempty"`
+}
+
+// SecretKeyRef references a key of a JSON secret of the provider
+// +kubebuilder:validation:XValidation:rule="!(has(self.versionStage) && has(self.versionId))",message="only one of versionStage and versionId can be set"
+type SecretKeyRef struct {
+	// Name of the secret
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key in the JSON secret. If the secret has no top-level key with that name, it is read as a path to a nested
+	// value, e.g. db.primary.password, db.replicas[0].host or $.db["key.with.dots"]
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+
+	// Optional skips the field when the key does not exist in the secret, instead of failing the sync
+	// +optional
+	Optional bool `json:"optional,omitempty"`
+
+	// VersionStage of the secret to use, e.g. AWSPREVIOUS. Defaults to AWSCURRENT.
+	// +optional
+	VersionStage string `json:"versionStage,omitempty"`
+
+	// VersionID of the secret to use, instead of the version with stage AWSCURRENT
+	// +optional
+	VersionID string `json:"versionId,omitempty"`
+}
+
+// ValueFrom defines where the value of a field is read from. Exactly one source must be set.
+// +kubebuilder:validation:XValidation:rule="[has(self.secretRef), has(self.secretKeyRef), has(self.template), has(self.templateRef)].filter(x, x).size() == 1",message="exactly one of secretRef, secretKeyRef, template and templateRef must be set"
+type ValueFrom struct {
+	// SecretRef writes a whole secret as the value
+	// +optional
+	SecretRef *SecretRef `json:"secretRef,omitempty"`
+
+	// SecretKeyRef writes a key of a JSON secret as the value
+	// +optional
+	SecretKeyRef *SecretKeyRef `json:"secretKeyRef,omitempty"`
+
+	// Template renders the value with Go templates
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// TemplateRef renders the value with a SecretTemplate
+	// +optional
+	TemplateRef *TemplateRef `json:"templateRef,omitempty"`
+
+	// DecodingStrategy applied to the value before it is written. Defaults to None.
+	// +optional
+	DecodingStrategy DecodingStrategy `json:"decodingStrategy,omitempty"`
+}
+
+// TemplateRef references a SecretTemplate
+type TemplateRef struct {
+	// Name of the SecretTemplate
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Parameters passed to the template, available as .Params
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// SecretField is a key of the generated Secret
+// +kubebuilder:validation:XValidation:rule="has(self.value) != has(self.valueFrom)",message="exactly one of value and valueFrom must be set"
+type SecretField struct {
+	// Name of the key
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Value written as is
+	// +optional
+	Value *string `json:"value,omitempty"`
+
+	// ValueFrom reads the value from the provider
+	// +optional
+	ValueFrom *ValueFrom `json:"valueFrom,omitempty"`
+}
+
+// DataFrom writes every key of a JSON secret to the Secret
+type DataFrom struct {
+	// SecretRef of the JSON secret
+	SecretRef SecretRef `json:"secretRef"`
+
+	// DecodingStrategy applied to every value of the secret. Defaults to None.
+	// +optional
+	DecodingStrategy DecodingStrategy `json:"decodingStrategy,omitempty"`
+
+	// Flatten writes nested objects as one key per value, joining the keys with dots: {"db": {"user": "foo"}}
+	// becomes the key db.user. Nested objects are otherwise written as JSON.
+	// +optional
+	Flatten bool `json:"flatten,omitempty"`
+}
+
+// TemplateFrom renders a YAML or JSON object with the optional keys data, labels and annotations, each a map of
+// strings. Data values that are not strings are written as JSON.
+// +kubebuilder:validation:XValidation:rule="has(self.template) != has(self.templateRef)",message="exactly one of template and templateRef must be set"
+type TemplateFrom struct {
+	// Template is a Go template
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// TemplateRef renders a SecretTemplate
+	// +optional
+	TemplateRef *TemplateRef `json:"templateRef,omitempty"`
+
+	// DecodingStrategy applied to the data values before they are written. Defaults to None.
+	// +optional
+	DecodingStrategy DecodingStrategy `json:"decodingStrategy,omitempty"`
+}
+
+// Target defines the generated Secret
+type Target struct {
+	// Name of the Secret. Defaults to the name of the SyncedSecret.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Namespace of the Secret. Defaults to the namespace of the SyncedSecret.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Labels of the Secret
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations of the Secret
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Type of the Secret, e.g. kubernetes.io/tls or kubernetes.io/dockerconfigjson. Defaults to Opaque.
+	// +optional
+	Type corev1.SecretType `json:"type,omitempty"`
+
+	// PropagateTags copies tags of the secrets read from the provider onto the labels or annotations of the
+	// Secret. Labels and annotations set above take precedence over them.
+	// +optional
+	PropagateTags *PropagateTags `json:"propagateTags,omitempty"`
+
+	// TemplateMetadata renders the values of the labels and annotations above as templates, with the same functions
+	// and data as templated fields. They are written as they are otherwise.
+	// +optional
+	TemplateMetadata bool `json:"templateMetadata,omitempty"`
+
+	// CreationPolicy defines how the Secret is written: Owner, Merge, Orphan or None. Defaults to Owner.
+	// +optional
+	CreationPolicy CreationPolicy `json:"creationPolicy,omitempty"`
+
+	// DeletionPolicy defines what happens to the Secret when the SyncedSecret is deleted: Delete, Retain or
+	// Orphan. Only applies to Secrets created with the Owner creation policy. Defaults to Delete.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Immutable writes every version of the Secret as a new immutable Secret named after the Secret name and the
+	// hash of its data, e.g. demo-service-secret-1a2b3c4d5e. Requires the Owner creation policy.
+	// +optional
+	Immutable bool `json:"immutable,omitempty"`
+
+	// ImmutableHistoryLimit is the number of previous immutable Secrets kept. Defaults to 3.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ImmutableHistoryLimit *int32 `json:"immutableHistoryLimit,omitempty"`
+}
+
+// SyncedSecretSpec defines the desired state of SyncedSecret
+type SyncedSecretSpec struct {
+	// Provider the secrets are read from
+	Provider Provider `json:"provider"`
+
+	// Target defines the generated Secret
+	// +optional
+	Target Target `json:"target,omitempty"`
+
+	// Data lists the keys of the Secret
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Data []SecretField `json:"data,omitempty"`
+
+	// DataFrom writes every key of a JSON secret to the Secret
+	// +optional
+	DataFrom *DataFrom `json:"dataFrom,omitempty"`
+
+	// TemplateFrom renders a template into the data, labels and annotations of the Secret. Its keys override the
+	// keys of dataFrom, and are overridden by the fields of data and the target.
+	// +optional
+	TemplateFrom *TemplateFrom `json:"templateFrom,omitempty"`
+
+	// RefreshInterval is how often the Secret is refreshed from the provider, e.g. 1m. Intervals shorter than the
+	// minimum configured for the cluster are raised to it. Defaults to the global sync interval.
+	// +optional
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+
+	// Suspend stops syncing the Secret, leaving it as it is until Suspend is unset
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// SyncedSecretStatus defines the observed state of SyncedSecret
+type SyncedSecretStatus struct {
+	// SecretHash is the hash of the data of the generated Secret
+	// +optional
+	SecretHash string `json:"secretHash,omitempty"`
+
+	// CurrentSecretName is the name of the generated Secret, which changes with every version of immutable Secrets
+	// +optional
+	CurrentSecretName string `json:"currentSecretName,omitempty"`
+
+	// CurrentSecretNamespace is the namespace of the generated Secret
+	// +optional
+	CurrentSecretNamespace string `json:"currentSecretNamespace,omitempty"`
+
+	// CurrentVersionID is the version of the secret the Secret was generated from, only set when the SyncedSecret
+	// references a single secret
+	// +optional
+	CurrentVersionID string `json:"currentVersionID,omitempty"`
+
+	// SourceVersions maps the ID of every secret used to generate the Secret to its version
+	// +optional
+	SourceVersions map[string]string `json:"sourceVersions,omitempty"`
+
+	// ObservedGeneration is the generation of the SyncedSecret that was last reconciled
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastSyncTime is the last time the Secret was successfully synced
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Conditions represent the latest observations of the SyncedSecret's state
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Last Sync",type=date,JSONPath=`.status.lastSyncTime`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// SyncedSecret is the Schema for the SyncedSecrets API
+type SyncedSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SyncedSecretSpec   `json:"spec,omitempty"`
+	Status SyncedSecretStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SyncedSecretList contains a list of SyncedSecret
+type SyncedSecretList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SyncedSecret `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SyncedSecret{}, &SyncedSecretList{})
+}
@@ -0,0 +1,271 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	"github.com/contentful-labs/kube-secret-syncer/pkg/k8ssecret"
+)
+
+type NamespaceWatcher interface {
+	List() ([]*corev1.Namespace, error)
+	OnChange(func(*corev1.Namespace))
+}
+
+// ClusterSyncedSecretReconciler reconciles a ClusterSyncedSecret object
+type ClusterSyncedSecretReconciler struct {
+	client.Client
+	// SyncedSecrets is used to validate and write the Secret in each selected namespace, the same way as for
+	// a SyncedSecret
+	SyncedSecrets *SyncedSecretReconciler
+	Namespaces    NamespaceWatcher
+	Log           logr.Logger
+}
+
+const (
+	LogFieldClusterSyncedSecret = "ClusterSyncedSecret"
+)
+
+// +kubebuilder:rbac:groups=secrets.contentful.com,resources=clustersyncedsecrets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=secrets.contentful.com,resources=clustersyncedsecrets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=delete
+
+func (r *ClusterSyncedSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var css secretsv1.ClusterSyncedSecret
+
+	log := r.Log.WithValues(LogFieldClusterSyncedSecret, req.Name)
+	if err := r.Get(ctx, req.NamespacedName, &css); err != nil {
+		log.Info("unable to fetch ClusterSyncedSecret, was maybe deleted")
+		return ctrl.Result{}, nil
+	}
+
+	if !css.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, &css, log)
+	}
+	if controllerutil.AddFinalizer(&css, Finalizer) {
+		if err := r.Update(ctx, &css); err != nil {
+			return ctrl.Result{}, errors.WithMessagef(err, "failed adding finalizer to ClusterSyncedSecret %s", css.Name)
+		}
+	}
+
+	if css.Spec.Suspend {
+		log.Info("syncing is suspended")
+		return ctrl.Result{}, r.updateStatus(ctx, &css, metav1.ConditionFalse, secretsv1.ReasonSuspended, "syncing is suspended", log)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&css.Spec.NamespaceSelector)
+	if err != nil {
+		log.Error(err, "invalid namespace selector")
+		return ctrl.Result{}, errors.WithMessagef(err, "invalid namespace selector for ClusterSyncedSecret %s", css.Name)
+	}
+
+	namespaces, err := r.Namespaces.List()
+	if err != nil {
+		return ctrl.Result{}, errors.WithMessage(err, "failed listing namespaces")
+	}
+
+	selectedNamespaces := map[string]bool{}
+	syncedNamespaces := []string{}
+	failedNamespaces := []string{}
+	for _, ns := range namespaces {
+		if ns.Status.Phase == corev1.NamespaceTerminating || !selector.Matches(labels.Set(ns.Labels)) {
+			continue
+		}
+		selectedNamespaces[ns.Name] = true
+
+		cs := syncedSecretForNamespace(&css, ns.Name)
+		nsLog := log.WithValues(LogFieldK8SSecret, k8ssecret.SecretName(*cs).String())
+
+		if err := r.SyncedSecrets.validateAccess(cs, ns.Name, nsLog); err != nil {
+			nsLog.Error(err, "secret not allowed in namespace")
+			failedNamespaces = append(failedNamespaces, ns.Name)
+			continue
+		}
+
+		if _, _, err := r.SyncedSecrets.syncK8SSecret(ctx, cs, k8ssecret.AnnotationClusterSyncedSecret, css.Name, nsLog); err != nil {
+			nsLog.Error(err, "failed syncing secret")
+			failedNamespaces = append(failedNamespaces, ns.Name)
+			continue
+		}
+		syncedNamespaces = append(syncedNamespaces, ns.Name)
+	}
+
+	// Remove the Secret from namespaces that are not selected anymore
+	for _, namespace := range append(css.Status.SyncedNamespaces, css.Status.FailedNamespaces...) {
+		if selectedNamespaces[namespace] {
+			continue
+		}
+
+		cs := syncedSecretForNamespace(&css, namespace)
+		if err := r.SyncedSecrets.releaseK8SSecret(ctx, cs, k8ssecret.AnnotationClusterSyncedSecret, css.Name, log); err != nil {
+			log.Error(err, "failed releasing secret from namespace", "namespace", namespace)
+			failedNamespaces = append(failedNamespaces, namespace)
+		}
+	}
+
+	sort.Strings(syncedNamespaces)
+	sort.Strings(failedNamespaces)
+	css.Status.SyncedNamespaces = syncedNamespaces
+	css.Status.FailedNamespaces = failedNamespaces
+	if len(failedNamespaces) > 0 {
+		message := fmt.Sprintf("failed syncing in namespaces %s", strings.Join(failedNamespaces, ", "))
+		if err := r.updateStatus(ctx, &css, metav1.ConditionFalse, secretsv1.ReasonSyncFailed, message, log); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, fmt.Errorf("failed syncing ClusterSyncedSecret %s in namespaces %s", css.Name, strings.Join(failedNamespaces, ", "))
+	}
+	message := fmt.Sprintf("synced to %d namespaces", len(syncedNamespaces))
+	if err := r.updateStatus(ctx, &css, metav1.ConditionTrue, secretsv1.ReasonSynced, message, log); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.SyncedSecrets.refreshInterval(&css.Spec.SyncedSecretSpec)}, nil
+}
+
+// updateStatus records the result of a reconcile in the ClusterSyncedSecret's Ready condition
+func (r *ClusterSyncedSecretReconciler) updateStatus(ctx context.Context, css *secretsv1.ClusterSyncedSecret, status metav1.ConditionStatus, reason, message string, log logr.Logger) error {
+	css.Status.ObservedGeneration = css.Generation
+	meta.SetStatusCondition(&css.Status.Conditions, metav1.Condition{
+		Type:               secretsv1.ConditionTypeReady,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: css.Generation,
+	})
+	if err := r.Status().Update(ctx, css); err != nil {
+		log.Error(err, "failed to update ClusterSyncedSecret status")
+		return errors.WithMessagef(err, "failed to update ClusterSyncedSecret status for %s", css.Name)
+	}
+	return nil
+}
+
+// syncedSecretForNamespace returns the SyncedSecret equivalent to a ClusterSyncedSecret for a single namespace
+func syncedSecretForNamespace(css *secretsv1.ClusterSyncedSecret, namespace string) *secretsv1.SyncedSecret {
+	cs := &secretsv1.SyncedSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      css.Name,
+			Namespace: namespace,
+		},
+		Spec: *css.Spec.SyncedSecretSpec.DeepCopy(),
+	}
+	cs.Spec.SecretMetadata.Namespace = namespace
+
+	// record the Secret written to the namespace by a previous sync, as a SyncedSecret does in its status
+	for _, synced := range css.Status.SyncedNamespaces {
+		if synced == namespace {
+			cs.Status.CurrentSecretName = k8ssecret.SecretName(*cs).Name
+			cs.Status.CurrentSecretNamespace = namespace
+		}
+	}
+
+	return cs
+}
+
+// finalize applies the deletion policy of a deleted ClusterSyncedSecret to the Secrets it generated, and lets the
+// deletion proceed
+func (r *ClusterSyncedSecretReconciler) finalize(ctx context.Context, css *secretsv1.ClusterSyncedSecret, log logr.Logger) error {
+	if !controllerutil.ContainsFinalizer(css, Finalizer) {
+		return nil
+	}
+
+	for _, namespace := range append(css.Status.SyncedNamespaces, css.Status.FailedNamespaces...) {
+		cs := syncedSecretForNamespace(css, namespace)
+		if err := r.SyncedSecrets.releaseK8SSecret(ctx, cs, k8ssecret.AnnotationClusterSyncedSecret, css.Name, log); err != nil {
+			return errors.WithMessagef(err, "failed releasing secret from namespace %s", namespace)
+		}
+	}
+
+	controllerutil.RemoveFinalizer(css, Finalizer)
+	if err := r.Update(ctx, css); err != nil {
+		return errors.WithMessagef(err, "failed removing finalizer from ClusterSyncedSecret %s", css.Name)
+	}
+
+	return nil
+}
+
+// clusterSyncedSecretsForNamespace enqueues all ClusterSyncedSecrets when a namespace changes, as it might have
+// started or stopped matching their selector
+func (r *ClusterSyncedSecretReconciler) clusterSyncedSecretsForNamespace(ctx context.Context, _ client.Object) []reconcile.Request {
+	var clusterSyncedSecrets secretsv1.ClusterSyncedSecretList
+	if err := r.List(ctx, &clusterSyncedSecrets); err != nil {
+		r.Log.Error(err, "failed listing ClusterSyncedSecrets")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, css := range clusterSyncedSecrets.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: css.Name}})
+	}
+
+	return requests
+}
+
+// clusterSyncedSecretsForTemplate enqueues the ClusterSyncedSecrets with templated fields when a SecretTemplate
+// changes
+func (r *ClusterSyncedSecretReconciler) clusterSyncedSecretsForTemplate(ctx context.Context, _ client.Object) []reconcile.Request {
+	var clusterSyncedSecrets secretsv1.ClusterSyncedSecretList
+	if err := r.List(ctx, &clusterSyncedSecrets); err != nil {
+		r.Log.Error(err, "failed listing ClusterSyncedSecrets")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, css := range clusterSyncedSecrets.Items {
+		if k8ssecret.UsesTemplates(css.Spec.SyncedSecretSpec) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: css.Name}})
+		}
+	}
+
+	return requests
+}
+
+func (r *ClusterSyncedSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// OnChange handlers run in the namespace informer, so they must not block. Every namespace event enqueues all
+	// ClusterSyncedSecrets, so an event can be dropped while another one is still waiting in the channel.
+	namespaceEvents := make(chan event.GenericEvent, 1)
+	r.Namespaces.OnChange(func(ns *corev1.Namespace) {
+		select {
+		case namespaceEvents <- event.GenericEvent{Object: ns}:
+		default:
+		}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&secretsv1.ClusterSyncedSecret{}).
+		Watches(&secretsv1.SecretTemplate{}, handler.EnqueueRequestsFromMapFunc(r.clusterSyncedSecretsForTemplate)).
+		WatchesRawSource(source.Channel(namespaceEvents, handler.EnqueueRequestsFromMapFunc(r.clusterSyncedSecretsForNamespace))).
+		Complete(r)
+}
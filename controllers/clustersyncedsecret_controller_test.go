@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("ClusterSyncedSecret Controller", func() {
+	const timeout = time.Minute * 3
+	const interval = time.Second * 2
+
+	Context("For a ClusterSyncedSecret", func() {
+		clusterSecretKey := types.NamespacedName{
+			Name: "cluster-secret-name",
+		}
+
+		It("Should create the K8S Secret in every selected namespace", func() {
+			toCreate := &secretsv1.ClusterSyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: clusterSecretKey.Name,
+				},
+				Spec: secretsv1.ClusterSyncedSecretSpec{
+					NamespaceSelector: metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{
+								Key:      "kubernetes.io/metadata.name",
+								Operator: metav1.LabelSelectorOpIn,
+								Values:   []string{TEST_NAMESPACE2, TEST_NAMESPACE3},
+							},
+						},
+					},
+					SyncedSecretSpec: secretsv1.SyncedSecretSpec{
+						IAMRole: _s("test"),
+						Data: []*secretsv1.SecretField{
+							{
+								Name:  _s("API_KEY"),
+								Value: _s("shared-api-key"),
+							},
+						},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			for _, namespace := range []string{TEST_NAMESPACE2, TEST_NAMESPACE3} {
+				secretKey := types.NamespacedName{Name: clusterSecretKey.Name, Namespace: namespace}
+				fetchedSecret := &corev1.Secret{}
+				Eventually(func() bool {
+					err := k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+					return k8serrors.IsNotFound(err)
+				}, timeout, interval).Should(BeFalse())
+
+				Expect(fetchedSecret.Data).To(Equal(map[string][]byte{"API_KEY": []byte("shared-api-key")}))
+			}
+
+			err := k8sClient.Get(context.Background(), types.NamespacedName{Name: clusterSecretKey.Name, Namespace: TEST_NAMESPACE}, &corev1.Secret{})
+			Expect(k8serrors.IsNotFound(err)).To(BeTrue())
+
+			fetchedClusterSecret := &secretsv1.ClusterSyncedSecret{}
+			Eventually(func() []string {
+				k8sClient.Get(context.Background(), clusterSecretKey, fetchedClusterSecret)
+				return fetchedClusterSecret.Status.SyncedNamespaces
+			}, timeout, interval).Should(Equal([]string{TEST_NAMESPACE2, TEST_NAMESPACE3}))
+		})
+
+		It("Should delete the K8S Secret from namespaces that are not selected anymore", func() {
+			fetchedClusterSecret := &secretsv1.ClusterSyncedSecret{}
+			Expect(k8sClient.Get(context.Background(), clusterSecretKey, fetchedClusterSecret)).Should(Succeed())
+
+			fetchedClusterSecret.Spec.NamespaceSelector.MatchExpressions[0].Values = []string{TEST_NAMESPACE2}
+			Expect(k8sClient.Update(context.Background(), fetchedClusterSecret)).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), types.NamespacedName{Name: clusterSecretKey.Name, Namespace: TEST_NAMESPACE3}, &corev1.Secret{})
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+
+			err := k8sClient.Get(context.Background(), types.NamespacedName{Name: clusterSecretKey.Name, Namespace: TEST_NAMESPACE2}, &corev1.Secret{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Should report that syncing is suspended", func() {
+			fetchedClusterSecret := &secretsv1.ClusterSyncedSecret{}
+			Expect(k8sClient.Get(context.Background(), clusterSecretKey, fetchedClusterSecret)).Should(Succeed())
+
+			fetchedClusterSecret.Spec.Suspend = true
+			Expect(k8sClient.Update(context.Background(), fetchedClusterSecret)).Should(Succeed())
+
+			Eventually(func() string {
+				k8sClient.Get(context.Background(), clusterSecretKey, fetchedClusterSecret)
+				condition := meta.FindStatusCondition(fetchedClusterSecret.Status.Conditions, secretsv1.ConditionTypeReady)
+				if condition == nil || condition.Status != metav1.ConditionFalse {
+					return ""
+				}
+				return condition.Reason
+			}, timeout, interval).Should(Equal(secretsv1.ReasonSuspended))
+		})
+	})
+	Context("For a templated ClusterSyncedSecret", func() {
+		clusterSecretKey := types.NamespacedName{
+			Name: "cluster-templated-secret",
+		}
+		templateKey := types.NamespacedName{
+			Name: "cluster-api-url",
+		}
+
+		It("Should render the K8S Secret again when the SecretTemplate changes", func() {
+			template := &secretsv1.SecretTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: templateKey.Name,
+				},
+				Spec: secretsv1.SecretTemplateSpec{
+					Template: `https://api.example.com/{{ .Params.version }}`,
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), template)).Should(Succeed())
+
+			toCreate := &secretsv1.ClusterSyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: clusterSecretKey.Name,
+				},
+				Spec: secretsv1.ClusterSyncedSecretSpec{
+					NamespaceSelector: metav1.LabelSelector{
+						MatchLabels: map[string]string{"kubernetes.io/metadata.name": TEST_NAMESPACE2},
+					},
+					SyncedSecretSpec: secretsv1.SyncedSecretSpec{
+						IAMRole: _s("test"),
+						Data: []*secretsv1.SecretField{
+							{
+								Name: _s("API_URL"),
+								ValueFrom: &secretsv1.ValueFrom{
+									TemplateRef: &secretsv1.TemplateRef{Name: templateKey.Name, Parameters: map[string]string{"version": "v1"}},
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			secretKey := types.NamespacedName{Name: clusterSecretKey.Name, Namespace: TEST_NAMESPACE2}
+			fetchedSecret := &corev1.Secret{}
+			Eventually(func() []byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data["API_URL"]
+			}, timeout, interval).Should(Equal([]byte("https://api.example.com/v1")))
+
+			fetchedTemplate := &secretsv1.SecretTemplate{}
+			Expect(k8sClient.Get(context.Background(), templateKey, fetchedTemplate)).Should(Succeed())
+			fetchedTemplate.Spec.Template = `https://api.example.org/{{ .Params.version }}`
+			Expect(k8sClient.Update(context.Background(), fetchedTemplate)).Should(Succeed())
+
+			Eventually(func() []byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data["API_URL"]
+			}, timeout, interval).Should(Equal([]byte("https://api.example.org/v1")))
+		})
+	})
+})
@@ -33,9 +33,11 @@ import (
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	secretsv2 "github.com/contentful-labs/kube-secret-syncer/api/v2"
 	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -44,6 +46,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	zap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -102,13 +105,45 @@ func (m *mockNamespaceValidator) HasNamespaceType(secretsmanager.DescribeSecretO
 	return true, nil
 }
 
+type mockTargetNamespaceValidator struct{}
+
+func (m *mockTargetNamespaceValidator) AllowsSourceNamespace(targetNamespace, sourceNamespace string) (bool, error) {
+	return targetNamespace == sourceNamespace || targetNamespace == TEST_NAMESPACE2, nil
+}
+
+// mockNamespaceWatcher lists namespaces from the API server, namespace changes are picked up on resync
+type mockNamespaceWatcher struct{}
+
+func (m *mockNamespaceWatcher) List() ([]*corev1.Namespace, error) {
+	var namespaces corev1.NamespaceList
+	if err := k8sClient.List(context.Background(), &namespaces); err != nil {
+		return nil, err
+	}
+
+	list := []*corev1.Namespace{}
+	for i := range namespaces.Items {
+		list = append(list, &namespaces.Items[i])
+	}
+	return list, nil
+}
+
+func (m *mockNamespaceWatcher) OnChange(func(*corev1.Namespace)) {}
+
+func (m *mockNamespaceWatcher) Get(name string) (*corev1.Namespace, error) {
+	var namespace corev1.Namespace
+	if err := k8sClient.Get(context.Background(), types.NamespacedName{Name: name}, &namespace); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return &namespace, nil
+}
+
 // TODO this needs to be more dynamic when an update comes by
 func (m *mockSecretsManagerClient) ListSecretsPages(input *secretsmanager.ListSecretsInput, fn func(*secretsmanager.ListSecretsOutput, bool) bool) error {
 	fn(MockSecretsOutput.SecretsPageOutput, true)
 	return nil
 }
 
-func (m *mockSecretsManagerClient) GetSecretValue(*secretsmanager.GetSecretValueInput) (*secretsmanager.GetSecretValueOutput, error) {
+func (m *mockSecretsManagerClient) GetSecretValueWithContext(aws.Context, *secretsmanager.GetSecretValueInput, ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
 	return MockSecretsOutput.SecretsValueOutput, nil
 }
 
@@ -129,24 +164,34 @@ var _ = BeforeSuite(func(done Done) {
 	logf.SetLogger(zap.New(zap.WriteTo(GinkgoWriter)))
 
 	By("bootstrapping test environment")
+	// the schemes are needed before starting the test environment, for it to set up the conversion webhooks
+	err := secretsv1.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+	err = secretsv2.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	testEnv = &envtest.Environment{
 		CRDDirectoryPaths: []string{filepath.Join("..", "config", "crd", "bases")},
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			Paths: []string{filepath.Join("..", "config", "webhook")},
+		},
 	}
 
-	var err error
 	cfg, err = testEnv.Start()
 	Expect(err).ToNot(HaveOccurred())
 	Expect(cfg).ToNot(BeNil())
 
-	err = secretsv1.AddToScheme(scheme.Scheme)
-	Expect(err).NotTo(HaveOccurred())
-
 	// +kubebuilder:scaffold:scheme
 
 	syncPeriod := 2 * time.Second
 	k8sManager, err = ctrl.NewManager(cfg, ctrl.Options{
 		Scheme: scheme.Scheme,
 		Cache:  cache.Options{SyncPeriod: &syncPeriod},
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Host:    testEnv.WebhookInstallOptions.LocalServingHost,
+			Port:    testEnv.WebhookInstallOptions.LocalServingPort,
+			CertDir: testEnv.WebhookInstallOptions.LocalServingCertDir,
+		}),
 		// SyncPeriod: &syncPeriod,
 	})
 	Expect(err).ToNot(HaveOccurred())
@@ -217,18 +262,36 @@ var _ = BeforeSuite(func(done Done) {
 
 	// mock the manager setup
 	Retry5Cfg := request.WithRetryer(aws.NewConfig(), awsclient.DefaultRetryer{NumMaxRetries: 5})
-	err = (&SyncedSecretReconciler{
+	syncedSecretReconciler := &SyncedSecretReconciler{
 		Client: k8sManager.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("SyncedSecret"),
 		Sess:   session.New(Retry5Cfg),
 		GetSMClient: func(IAMRole string) (secretsmanageriface.SecretsManagerAPI, error) {
 			return &smSvc, nil
 		},
-		RoleValidator:      &mockRoleValidator{},
-		NamespaceValidator: &mockNamespaceValidator{},
-		gauges:             map[string]prometheus.Gauge{},
-		sync_state:         map[string]bool{},
-		PollInterval:       3 * time.Second,
+		RoleValidator:            &mockRoleValidator{},
+		NamespaceValidator:       &mockNamespaceValidator{},
+		TargetNamespaceValidator: &mockTargetNamespaceValidator{},
+		Namespaces:               &mockNamespaceWatcher{},
+		ClusterVariables:         map[string]string{"region": "eu-west-1"},
+		gauges:                   map[string]prometheus.Gauge{},
+		sync_state:               map[string]bool{},
+		PollInterval:             3 * time.Second,
+	}
+	err = syncedSecretReconciler.SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&SyncedSecretValidator{
+		RoleValidator: &mockRoleValidator{},
+		Log:           ctrl.Log.WithName("webhooks").WithName("SyncedSecret"),
+	}).SetupWebhookWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&ClusterSyncedSecretReconciler{
+		Client:        k8sManager.GetClient(),
+		Log:           ctrl.Log.WithName("controllers").WithName("ClusterSyncedSecret"),
+		SyncedSecrets: syncedSecretReconciler,
+		Namespaces:    &mockNamespaceWatcher{},
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
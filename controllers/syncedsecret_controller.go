@@ -18,6 +18,9 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,6 +28,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 
@@ -32,9 +36,17 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	awssecretsmanager "github.com/aws/aws-sdk-go/service/secretsmanager"
 	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
@@ -51,20 +63,30 @@ type NamespaceValidator interface {
 	HasNamespaceType(secret awssecretsmanager.DescribeSecretOutput, namespace string) (bool, error)
 }
 
+type TargetNamespaceValidator interface {
+	AllowsSourceNamespace(targetNamespace, sourceNamespace string) (bool, error)
+}
+
 // SyncedSecretReconciler reconciles a SyncedSecret object
 type SyncedSecretReconciler struct {
 	client.Client
-	Sess               *session.Session
-	GetSMClient        func(string) (secretsmanageriface.SecretsManagerAPI, error)
-	poller             *secretsmanager.Poller
-	getNamespace       k8snamespace.NamespaceGetter
-	RoleValidator      RoleValidator
-	NamespaceValidator NamespaceValidator
-	PollInterval       time.Duration
-	Log                logr.Logger
-	wg                 sync.WaitGroup
+	Sess                     *session.Session
+	GetSMClient              func(string) (secretsmanageriface.SecretsManagerAPI, error)
+	poller                   *secretsmanager.Poller
+	Namespaces               k8snamespace.NamespaceGetter
+	RoleValidator            RoleValidator
+	NamespaceValidator       NamespaceValidator
+	TargetNamespaceValidator TargetNamespaceValidator
+	PollInterval             time.Duration
+	MinRefreshInterval       time.Duration
+	Log                      logr.Logger
+	wg                       sync.WaitGroup
 
 	DefaultSearchRole string
+	// ClusterVariables are available to templates as .Cluster
+	ClusterVariables map[string]string
+	// TemplateLimits bounds the rendering of the templates of each SyncedSecret
+	TemplateLimits k8ssecret.TemplateLimits
 
 	gauges     map[string]prometheus.Gauge
 	sync_state map[string]bool
@@ -73,12 +95,20 @@ type SyncedSecretReconciler struct {
 const (
 	LogFieldSyncedSecret = "SyncedSecret"
 	LogFieldK8SSecret    = "KubernetesSecret"
+
+	// Finalizer is set on SyncedSecrets and ClusterSyncedSecrets to apply their deletion policy to the Secrets they
+	// manage before they are deleted. Owner references can not be used, as Secrets can be written in other namespaces.
+	Finalizer = "secrets.contentful.com/finalizer"
+
+	// defaultImmutableHistoryLimit is the number of previous immutable Secrets kept when no history limit is set
+	defaultImmutableHistoryLimit = 3
 )
 
 // +kubebuilder:rbac:groups=secrets.contentful.com,resources=syncedsecrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=secrets.contentful.com,resources=syncedsecrets/status,verbs=get;update;patch
-// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups=secrets.contentful.com,resources=secrettemplates,verbs=get;list;watch
 
 func (r *SyncedSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var err error
@@ -92,14 +122,370 @@ func (r *SyncedSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, nil
 	}
 
-	// even though the SyncedSecret can contain name and namespace for the k8s secret to be created, we are disregarding it.
-	// the generated secret will have the same name/namesapce as the CRD
-	K8SSecretName := types.NamespacedName{
-		Name:      cs.ObjectMeta.Name,
-		Namespace: cs.ObjectMeta.Namespace,
-	}
+	// the generated secret will have the same name/namespace as the CRD, unless set in the secret metadata
+	K8SSecretName := k8ssecret.SecretName(cs)
 	log = log.WithValues(LogFieldK8SSecret, K8SSecretName.String())
 
+	if !cs.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, &cs, req.NamespacedName.String(), log)
+	}
+	if controllerutil.AddFinalizer(&cs, Finalizer) {
+		if err = r.Update(ctx, &cs); err != nil {
+			return ctrl.Result{}, errors.WithMessagef(err, "failed adding finalizer to SyncedSecret %s", req.NamespacedName)
+		}
+	}
+
+	if cs.Spec.Suspend {
+		return r.syncSuspended(ctx, &cs, log)
+	}
+
+	// all further checks are done against the namespace the Secret is written to, as this is where its values
+	// become readable
+	targetNamespace := K8SSecretName.Namespace
+	if targetNamespace != cs.Namespace {
+		allowed, err := r.TargetNamespaceValidator.AllowsSourceNamespace(targetNamespace, cs.Namespace)
+		if err != nil {
+			log.Error(err, "failed verifying if target namespace allows secrets from namespace", "namespace", cs.Namespace, "targetNamespace", targetNamespace)
+			return r.syncFailed(ctx, &cs, errors.WithMessagef(err, "failed verifying target namespace %s", targetNamespace), log)
+		}
+		if !allowed {
+			log.Info("target namespace does not allow secrets from namespace", "namespace", cs.Namespace, "targetNamespace", targetNamespace)
+			err = fmt.Errorf("SyncedSecrets in namespace %s are not allowed to write secrets in namespace %s", cs.Namespace, targetNamespace)
+			return r.syncFailed(ctx, &cs, withReason(secretsv1.ReasonNamespaceNotAllowed, err), log)
+		}
+	}
+
+	if err = r.validateAccess(&cs, targetNamespace, log); err != nil {
+		return r.syncFailed(ctx, &cs, err, log)
+	}
+
+	secret, sourceVersions, err := r.syncK8SSecret(ctx, &cs, k8ssecret.AnnotationSyncedSecret, req.NamespacedName.String(), log)
+	if err != nil {
+		return r.syncFailed(ctx, &cs, err, log)
+	}
+
+	if err = r.releasePreviousK8SSecrets(ctx, &cs, secret, k8ssecret.AnnotationSyncedSecret, req.NamespacedName.String(), log); err != nil {
+		return r.syncFailed(ctx, &cs, err, log)
+	}
+
+	cs.Status.SecretHash = k8ssecret.SecretHash(secret)
+	cs.Status.CurrentSecretName = secret.Name
+	cs.Status.CurrentSecretNamespace = secret.Namespace
+	cs.Status.SourceVersions = sourceVersions
+	cs.Status.CurrentVersionID = ""
+	if len(sourceVersions) == 1 {
+		for _, versionID := range sourceVersions {
+			cs.Status.CurrentVersionID = versionID
+		}
+	}
+	now := metav1.Now()
+	cs.Status.LastSyncTime = &now
+	cs.Status.ObservedGeneration = cs.Generation
+	meta.SetStatusCondition(&cs.Status.Conditions, metav1.Condition{
+		Type:               secretsv1.ConditionTypeReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             secretsv1.ReasonSynced,
+		Message:            fmt.Sprintf("k8s secret %s is in sync", K8SSecretName),
+		ObservedGeneration: cs.Generation,
+	})
+
+	if err = r.updateCSStatus(ctx, &cs); err != nil {
+		r.sync_state[cs.Name] = false
+		log.Error(err, "failed to update SyncedSecret status")
+		return ctrl.Result{}, errors.WithMessagef(err, "failed to update SyncedSecret status for %s", K8SSecretName)
+	}
+
+	r.sync_state[cs.Name] = true
+
+	return ctrl.Result{RequeueAfter: r.refreshInterval(&cs.Spec)}, nil
+}
+
+// refreshInterval returns how often a SyncedSecret should be refreshed, or 0 to rely on the global sync interval
+func (r *SyncedSecretReconciler) refreshInterval(spec *secretsv1.SyncedSecretSpec) time.Duration {
+	if spec.Suspend || spec.RefreshInterval == nil || spec.RefreshInterval.Duration <= 0 {
+		return 0
+	}
+	if spec.RefreshInterval.Duration < r.MinRefreshInterval {
+		return r.MinRefreshInterval
+	}
+	return spec.RefreshInterval.Duration
+}
+
+// finalize applies the deletion policy of a deleted SyncedSecret to its Secret, and lets the deletion proceed
+func (r *SyncedSecretReconciler) finalize(ctx context.Context, cs *secretsv1.SyncedSecret, owner string, log logr.Logger) error {
+	if !controllerutil.ContainsFinalizer(cs, Finalizer) {
+		return nil
+	}
+
+	if err := r.releaseK8SSecret(ctx, cs, k8ssecret.AnnotationSyncedSecret, owner, log); err != nil {
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(cs, Finalizer)
+	if err := r.Update(ctx, cs); err != nil {
+		return errors.WithMessagef(err, "failed removing finalizer from SyncedSecret %s", owner)
+	}
+	delete(r.sync_state, cs.Name)
+
+	return nil
+}
+
+// releaseK8SSecret applies the deletion policy of a SyncedSecret to the Secrets it generated, once it stops managing them
+func (r *SyncedSecretReconciler) releaseK8SSecret(ctx context.Context, cs *secretsv1.SyncedSecret, ownerAnnotation, owner string, log logr.Logger) error {
+	var k8sSecrets []corev1.Secret
+	if cs.Spec.Immutable {
+		var err error
+		if k8sSecrets, err = r.listImmutableK8SSecrets(ctx, cs, ownerAnnotation, owner); err != nil {
+			return err
+		}
+	} else {
+		K8SSecretName := k8ssecret.SecretName(*cs)
+
+		var k8sSecret corev1.Secret
+		if err := r.Get(ctx, K8SSecretName, &k8sSecret); err != nil {
+			if k8serrors.IsNotFound(err) {
+				return nil
+			}
+			return errors.WithMessagef(err, "error retrieving k8s secret %s", K8SSecretName)
+		}
+		k8sSecrets = append(k8sSecrets, k8sSecret)
+	}
+
+	for i := range k8sSecrets {
+		if err := r.applyDeletionPolicy(ctx, cs, &k8sSecrets[i], ownerAnnotation, owner, log); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// releasePreviousK8SSecrets applies the deletion policy of a SyncedSecret to the Secrets it wrote before its secret
+// metadata was changed to another name or namespace. current is the Secret written to the new target.
+func (r *SyncedSecretReconciler) releasePreviousK8SSecrets(ctx context.Context, cs *secretsv1.SyncedSecret, current *corev1.Secret, ownerAnnotation, owner string, log logr.Logger) error {
+	previous := types.NamespacedName{Namespace: cs.Status.CurrentSecretNamespace, Name: cs.Status.CurrentSecretName}
+	if previous.Name == "" {
+		return nil
+	}
+	// the namespace was not recorded by previous versions of kube-secret-syncer
+	if previous.Namespace == "" {
+		previous.Namespace = current.Namespace
+	}
+	K8SSecretName := k8ssecret.SecretName(*cs)
+	isCurrent := func(namespace, name string) bool {
+		if namespace != current.Namespace {
+			return false
+		}
+		return name == current.Name || cs.Spec.Immutable && strings.HasPrefix(name, K8SSecretName.Name+"-")
+	}
+	if isCurrent(previous.Namespace, previous.Name) {
+		return nil
+	}
+
+	// immutable Secrets leave previous versions behind, so every Secret still managed by the SyncedSecret in the
+	// previous namespace is released
+	var k8sSecrets corev1.SecretList
+	if err := r.List(ctx, &k8sSecrets, client.InNamespace(previous.Namespace), client.MatchingLabels{k8ssecret.LabelManagedBy: k8ssecret.ManagedBy}); err != nil {
+		return errors.WithMessagef(err, "failed listing the previous k8s secrets of %s", owner)
+	}
+	for i := range k8sSecrets.Items {
+		k8sSecret := &k8sSecrets.Items[i]
+		if isCurrent(k8sSecret.Namespace, k8sSecret.Name) {
+			continue
+		}
+		if err := r.applyDeletionPolicy(ctx, cs, k8sSecret, ownerAnnotation, owner, log); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyDeletionPolicy deletes, orphans or retains a Secret that a SyncedSecret stops managing. Secrets managed by
+// someone else are left untouched.
+func (r *SyncedSecretReconciler) applyDeletionPolicy(ctx context.Context, cs *secretsv1.SyncedSecret, k8sSecret *corev1.Secret, ownerAnnotation, owner string, log logr.Logger) error {
+	K8SSecretName := client.ObjectKeyFromObject(k8sSecret)
+
+	// never touch a Secret managed by someone else
+	if k8sSecret.Annotations[ownerAnnotation] != owner {
+		return nil
+	}
+
+	switch cs.Spec.DeletionPolicy {
+	case secretsv1.DeletionPolicyRetain:
+		log.Info("retaining k8s secret", "K8SSecret", K8SSecretName.String())
+
+	case secretsv1.DeletionPolicyOrphan:
+		delete(k8sSecret.Annotations, ownerAnnotation)
+		if err := r.Update(ctx, k8sSecret); err != nil {
+			return errors.WithMessagef(err, "failed orphaning k8s secret %s", K8SSecretName)
+		}
+		log.Info("orphaned k8s secret", "K8SSecret", K8SSecretName.String())
+
+	default:
+		if err := r.Delete(ctx, k8sSecret); err != nil && !k8serrors.IsNotFound(err) {
+			return errors.WithMessagef(err, "failed deleting k8s secret %s", K8SSecretName)
+		}
+		log.Info("deleted k8s secret", "K8SSecret", K8SSecretName.String())
+	}
+
+	return nil
+}
+
+// listImmutableK8SSecrets returns the immutable Secrets generated by a SyncedSecret, newest first
+func (r *SyncedSecretReconciler) listImmutableK8SSecrets(ctx context.Context, cs *secretsv1.SyncedSecret, ownerAnnotation, owner string) ([]corev1.Secret, error) {
+	K8SSecretName := k8ssecret.SecretName(*cs)
+
+	var k8sSecrets corev1.SecretList
+	if err := r.List(ctx, &k8sSecrets, client.InNamespace(K8SSecretName.Namespace), client.MatchingLabels{k8ssecret.LabelManagedBy: k8ssecret.ManagedBy}); err != nil {
+		return nil, errors.WithMessagef(err, "failed listing immutable versions of k8s secret %s", K8SSecretName)
+	}
+
+	immutableSecrets := []corev1.Secret{}
+	for _, k8sSecret := range k8sSecrets.Items {
+		if k8sSecret.Annotations[ownerAnnotation] != owner || k8sSecret.Immutable == nil || !*k8sSecret.Immutable {
+			continue
+		}
+		if !strings.HasPrefix(k8sSecret.Name, K8SSecretName.Name+"-") {
+			continue
+		}
+		immutableSecrets = append(immutableSecrets, k8sSecret)
+	}
+
+	sort.SliceStable(immutableSecrets, func(i, j int) bool {
+		return immutableSecrets[j].CreationTimestamp.Before(&immutableSecrets[i].CreationTimestamp)
+	})
+
+	return immutableSecrets, nil
+}
+
+// pruneImmutableK8SSecrets deletes the immutable Secrets generated by a SyncedSecret, except for the current one and
+// the most recent ones within its history limit
+func (r *SyncedSecretReconciler) pruneImmutableK8SSecrets(ctx context.Context, cs *secretsv1.SyncedSecret, current, ownerAnnotation, owner string, log logr.Logger) error {
+	if !cs.Spec.Immutable {
+		return nil
+	}
+
+	historyLimit := defaultImmutableHistoryLimit
+	if cs.Spec.ImmutableHistoryLimit != nil {
+		historyLimit = int(*cs.Spec.ImmutableHistoryLimit)
+	}
+
+	k8sSecrets, err := r.listImmutableK8SSecrets(ctx, cs, ownerAnnotation, owner)
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	for i := range k8sSecrets {
+		if k8sSecrets[i].Name == current {
+			continue
+		}
+		if kept < historyLimit {
+			kept++
+			continue
+		}
+		if err := r.Delete(ctx, &k8sSecrets[i]); err != nil && !k8serrors.IsNotFound(err) {
+			return errors.WithMessagef(err, "failed deleting k8s secret %s", client.ObjectKeyFromObject(&k8sSecrets[i]))
+		}
+		log.Info("deleted previous version of immutable k8s secret", "K8SSecret", client.ObjectKeyFromObject(&k8sSecrets[i]).String())
+	}
+
+	return nil
+}
+
+// syncSuspended records in the SyncedSecret's Ready condition that its Secret is not synced, without reading from
+// Secrets Manager or writing the Secret
+func (r *SyncedSecretReconciler) syncSuspended(ctx context.Context, cs *secretsv1.SyncedSecret, log logr.Logger) (ctrl.Result, error) {
+	log.Info("syncing is suspended")
+	delete(r.sync_state, cs.Name)
+
+	cs.Status.ObservedGeneration = cs.Generation
+	meta.SetStatusCondition(&cs.Status.Conditions, metav1.Condition{
+		Type:               secretsv1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             secretsv1.ReasonSuspended,
+		Message:            fmt.Sprintf("syncing k8s secret %s is suspended", k8ssecret.SecretName(*cs)),
+		ObservedGeneration: cs.Generation,
+	})
+	if err := r.updateCSStatus(ctx, cs); err != nil {
+		log.Error(err, "failed to update SyncedSecret status")
+		return ctrl.Result{}, errors.WithMessagef(err, "failed to update SyncedSecret status for %s", k8ssecret.SecretName(*cs))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// syncFailed records a failed sync in the SyncedSecret's Ready condition, and returns err so the sync is retried
+func (r *SyncedSecretReconciler) syncFailed(ctx context.Context, cs *secretsv1.SyncedSecret, err error, log logr.Logger) (ctrl.Result, error) {
+	r.sync_state[cs.Name] = false
+
+	cs.Status.ObservedGeneration = cs.Generation
+	meta.SetStatusCondition(&cs.Status.Conditions, metav1.Condition{
+		Type:               secretsv1.ConditionTypeReady,
+		Status:             metav1.ConditionFalse,
+		Reason:             reasonFor(err),
+		Message:            err.Error(),
+		ObservedGeneration: cs.Generation,
+	})
+	if statusErr := r.updateCSStatus(ctx, cs); statusErr != nil {
+		log.Error(statusErr, "failed to update SyncedSecret status")
+	}
+
+	return ctrl.Result{}, err
+}
+
+// syncError attaches the reason reported in the Ready condition to an error
+type syncError struct {
+	reason string
+	err    error
+}
+
+func (e *syncError) Error() string {
+	return e.err.Error()
+}
+
+func (e *syncError) Unwrap() error {
+	return e.err
+}
+
+func withReason(reason string, err error) error {
+	return &syncError{reason: reason, err: err}
+}
+
+// reasonFor returns the Ready condition reason for an error returned while syncing
+func reasonFor(err error) string {
+	var se *syncError
+	if errors.As(err, &se) {
+		return se.reason
+	}
+	var tle *k8ssecret.TemplateLimitError
+	if errors.As(err, &tle) {
+		return secretsv1.ReasonTemplateLimitExceeded
+	}
+	var te *k8ssecret.TemplateError
+	if errors.As(err, &te) {
+		return secretsv1.ReasonTemplateError
+	}
+	var ke *k8ssecret.KeyNotFoundError
+	if errors.As(err, &ke) {
+		return secretsv1.ReasonKeyNotFound
+	}
+	var de *k8ssecret.DecodingError
+	if errors.As(err, &de) {
+		return secretsv1.ReasonDecodingFailed
+	}
+	return secretsv1.ReasonSyncFailed
+}
+
+// isSourceNotFound returns true if err was caused by a secret missing in Secrets Manager
+func isSourceNotFound(err error) bool {
+	var aerr awserr.Error
+	return errors.As(err, &aerr) && aerr.Code() == awssecretsmanager.ErrCodeResourceNotFoundException
+}
+
+// validateAccess verifies that the secrets referenced by a SyncedSecret are allowed to be written in namespace
+func (r *SyncedSecretReconciler) validateAccess(cs *secretsv1.SyncedSecret, namespace string, log logr.Logger) error {
 	if cs.Spec.AWSAccountID != nil {
 		IAMRole := fmt.Sprintf("arn:aws:iam::%s:role/secret-syncer", *cs.Spec.AWSAccountID)
 		var secretRef *string // secretID of the secret in secret Manager
@@ -109,13 +495,11 @@ func (r *SyncedSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			if cs.Spec.DataFrom.SecretRef != nil {
 				secretRef = cs.Spec.DataFrom.SecretRef.Name
 				if secretRef == nil {
-					return ctrl.Result{}, errors.WithMessagef(err, "secretRef name is invalid %s", *secretRef)
+					return fmt.Errorf("secretRef name is invalid")
 				}
 
-				allowed, err := r.secretAllowedInNamespace(*secretRef, IAMRole, cs.Namespace, cs.Name)
-
-				if !allowed || err != nil {
-					return ctrl.Result{}, errors.WithMessagef(err, "failed to validate if secret %s with role %s is allowed in namespace %s", *secretRef, IAMRole, cs.Namespace)
+				if err := r.secretAllowedInNamespace(*secretRef, IAMRole, namespace); err != nil {
+					return err
 				}
 			}
 
@@ -123,143 +507,349 @@ func (r *SyncedSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 		if cs.Spec.Data != nil {
 			for _, field := range cs.Spec.Data {
+				if field.ValueFrom == nil {
+					continue
+				}
 				if field.ValueFrom.SecretRef != nil {
+					secretRef = field.ValueFrom.SecretRef.Name
+				} else if field.ValueFrom.SecretKeyRef != nil {
 					secretRef = field.ValueFrom.SecretKeyRef.Name
-					if secretRef == nil {
-						return ctrl.Result{}, errors.WithMessagef(err, "secretRef name is invalid %s", *secretRef)
-					}
-
-					allowed, err := r.secretAllowedInNamespace(*secretRef, IAMRole, cs.Namespace, cs.Name)
+				} else {
+					continue
+				}
+				if secretRef == nil {
+					return fmt.Errorf("secretRef name is invalid")
+				}
 
-					if !allowed || err != nil {
-						return ctrl.Result{}, errors.WithMessagef(err, "failed to validate if secret %s with role %s is allowed in namespace %s", *secretRef, IAMRole, cs.Namespace)
-					}
+				if err := r.secretAllowedInNamespace(*secretRef, IAMRole, namespace); err != nil {
+					return err
 				}
 			}
 		}
 
-	} else {
-		allowed, err := r.RoleValidator.IsWhitelisted(*cs.Spec.IAMRole, cs.Namespace)
-		if !allowed {
-			r.sync_state[cs.Name] = false
-			log.Error(err, "role not allowed by namespace", "role", *cs.Spec.IAMRole, "namespace", cs.Namespace)
-			return ctrl.Result{}, errors.WithMessagef(err, "role %s not allowed in namespace %s", *cs.Spec.IAMRole, cs.Namespace)
+		return nil
+	}
+
+	if cs.Spec.IAMRole == nil {
+		return fmt.Errorf("one of IAMRole and AWSAccountID must be set")
+	}
+
+	allowed, err := r.RoleValidator.IsWhitelisted(*cs.Spec.IAMRole, namespace)
+	if !allowed {
+		log.Error(err, "role not allowed by namespace", "role", *cs.Spec.IAMRole, "namespace", namespace)
+		return withReason(secretsv1.ReasonRoleNotAllowed, errors.Errorf("role %s not allowed in namespace %s", *cs.Spec.IAMRole, namespace))
+	}
+	if err != nil {
+		log.Error(err, "failed verifying if IAMRole is whitelisted", "role", *cs.Spec.IAMRole, "namespace", namespace)
+		return errors.WithMessagef(err, "failed verifying role %s", *cs.Spec.IAMRole)
+	}
+
+	return nil
+}
+
+// syncK8SSecret writes the k8s Secret generated from a SyncedSecret according to its creation policy. A Secret owned by
+// the SyncedSecret is marked as managed by owner through ownerAnnotation, and a Secret managed by another SyncedSecret
+// or ClusterSyncedSecret is never written. It returns the Secret as written, and the version of each Secrets Manager
+// secret it was generated from.
+func (r *SyncedSecretReconciler) syncK8SSecret(ctx context.Context, cs *secretsv1.SyncedSecret, ownerAnnotation, owner string, log logr.Logger) (*corev1.Secret, map[string]string, error) {
+	K8SSecretName := k8ssecret.SecretName(*cs)
+
+	secret, sourceVersions, err := r.generateK8SSecret(ctx, cs)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed generating k8s secret %s", K8SSecretName)
+	}
+
+	creationPolicy := cs.Spec.CreationPolicy
+	if creationPolicy == "" {
+		creationPolicy = secretsv1.CreationPolicyOwner
+	}
+	if cs.Spec.Immutable {
+		if creationPolicy != secretsv1.CreationPolicyOwner {
+			return nil, nil, fmt.Errorf("immutable k8s secrets can only be written with the %s creation policy", secretsv1.CreationPolicyOwner)
 		}
-		if err != nil {
-			r.sync_state[cs.Name] = false
-			log.Error(err, "failed verifying if IAMRole is whitelisted", "role", *cs.Spec.IAMRole, "namespace", cs.Namespace)
-			return ctrl.Result{}, errors.WithMessagef(err, "failed verifying role %s: %s", *cs.Spec.IAMRole, err)
+		immutable := true
+		secret.Name = k8ssecret.ImmutableSecretName(secret.Name, secret)
+		secret.Immutable = &immutable
+		K8SSecretName.Name = secret.Name
+	}
+
+	switch creationPolicy {
+	case secretsv1.CreationPolicyNone:
+		return secret, sourceVersions, nil
+	case secretsv1.CreationPolicyOwner:
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[ownerAnnotation] = owner
+		if secret.Labels == nil {
+			secret.Labels = map[string]string{}
 		}
+		secret.Labels[k8ssecret.LabelManagedBy] = k8ssecret.ManagedBy
 	}
 
 	var k8sSecret corev1.Secret = corev1.Secret{}
 	err = r.Get(ctx, K8SSecretName, &k8sSecret)
 	if err != nil {
 		if !k8serrors.IsNotFound(err) {
-			r.sync_state[cs.Name] = false
-			return ctrl.Result{}, errors.WithMessagef(err, "error retrieving k8s secret %s", K8SSecretName)
+			return nil, nil, errors.WithMessagef(err, "error retrieving k8s secret %s", K8SSecretName)
+		}
+		if creationPolicy == secretsv1.CreationPolicyMerge {
+			return nil, nil, fmt.Errorf("k8s secret %s does not exist, it is not created with the %s creation policy", K8SSecretName, creationPolicy)
 		}
 
 		// Create the k8S secret if it was not found
-		createdSecret, err := r.createK8SSecret(ctx, &cs)
-		if err != nil {
-			r.sync_state[cs.Name] = false
-			return ctrl.Result{}, errors.WithMessagef(err, "failed creating K8S Secret %s", K8SSecretName)
+		if err = r.createK8SSecret(ctx, secret); err != nil {
+			return nil, nil, errors.WithMessagef(err, "failed creating K8S Secret %s", K8SSecretName)
 		}
-		log.Info("created k8s secret", "K8SSecret", createdSecret)
-	} else {
-		// Update the K8S Secret if it already exists
-		updatedSecret, err := r.updateK8SSecret(ctx, &cs)
-		if err != nil {
-			r.sync_state[cs.Name] = false
-			return ctrl.Result{}, errors.WithMessagef(err, "failed updating k8s secret %s", K8SSecretName)
+		if err = r.pruneImmutableK8SSecrets(ctx, cs, secret.Name, ownerAnnotation, owner, log); err != nil {
+			return nil, nil, err
 		}
-		if !k8ssecret.K8SSecretsEqual(k8sSecret, *updatedSecret) {
-			log.Info("updated secret", "K8SSecret", updatedSecret.ObjectMeta, "secretSize", k8ssecret.SecretLength(updatedSecret))
+		return secret, sourceVersions, nil
+	}
+
+	// Refuse to overwrite a Secret managed by another SyncedSecret or ClusterSyncedSecret
+	for _, annotation := range []string{k8ssecret.AnnotationSyncedSecret, k8ssecret.AnnotationClusterSyncedSecret} {
+		if otherOwner, ok := k8sSecret.Annotations[annotation]; ok && (annotation != ownerAnnotation || otherOwner != owner) {
+			log.Info("k8s secret is managed by another SyncedSecret", "owner", otherOwner)
+			return nil, nil, withReason(secretsv1.ReasonSecretConflict, fmt.Errorf("k8s secret %s is already managed by %s", K8SSecretName, otherOwner))
 		}
 	}
 
-	if err = r.updateCSStatus(ctx, &cs); err != nil {
-		r.sync_state[cs.Name] = false
-		log.Error(err, "failed to update SyncedSecret status")
-		return ctrl.Result{}, errors.WithMessagef(err, "failed to update SyncedSecret status for %s", K8SSecretName)
+	switch creationPolicy {
+	case secretsv1.CreationPolicyOwner:
+		// Only take over existing Secrets that were explicitly marked as managed by this SyncedSecret, or that were
+		// written by versions of kube-secret-syncer that did not set the annotation, at the name of the SyncedSecret
+		if k8sSecret.Annotations[ownerAnnotation] != owner {
+			if !isLegacySecret(cs, ownerAnnotation, &k8sSecret) {
+				log.Info("k8s secret exists and is not managed by kube-secret-syncer")
+				return nil, nil, withReason(secretsv1.ReasonSecretConflict, fmt.Errorf("k8s secret %s exists and is not managed by kube-secret-syncer, annotate it with %s=%s to take it over", K8SSecretName, ownerAnnotation, owner))
+			}
+			log.Info("adopting k8s secret written before secrets were annotated with their owner")
+		}
+	case secretsv1.CreationPolicyOrphan:
+		// Orphaned Secrets are not marked as managed, so only overwrite the Secret written by the last sync, or a
+		// Secret explicitly marked as managed by this SyncedSecret
+		if k8sSecret.Annotations[ownerAnnotation] != owner && !isCurrentSecret(cs, &k8sSecret) {
+			log.Info("k8s secret exists and was not written by this SyncedSecret")
+			return nil, nil, withReason(secretsv1.ReasonSecretConflict, fmt.Errorf("k8s secret %s exists and was not written by %s, the %s creation policy does not overwrite it", K8SSecretName, owner, creationPolicy))
+		}
+	case secretsv1.CreationPolicyMerge:
+		if cs.Spec.SecretMetadata.Type != "" && cs.Spec.SecretMetadata.Type != k8sSecret.Type {
+			return nil, nil, withReason(secretsv1.ReasonSecretTypeMismatch, fmt.Errorf("k8s secret %s is of type %s, the %s creation policy can not change it to %s", K8SSecretName, k8sSecret.Type, creationPolicy, cs.Spec.SecretMetadata.Type))
+		}
+		secret = k8ssecret.MergeK8SSecret(&k8sSecret, secret)
 	}
 
-	r.sync_state[cs.Name] = true
+	// The type of a Secret can not be updated: a Secret owned by the SyncedSecret is recreated with the new type
+	if secret.Type != k8sSecret.Type {
+		if creationPolicy != secretsv1.CreationPolicyOwner {
+			return nil, nil, withReason(secretsv1.ReasonSecretTypeMismatch, fmt.Errorf("k8s secret %s is of type %s, the %s creation policy can not change it to %s", K8SSecretName, k8sSecret.Type, creationPolicy, secret.Type))
+		}
+		log.Info("recreating k8s secret to change its type", "from", k8sSecret.Type, "to", secret.Type)
+		if err = r.Delete(ctx, &k8sSecret, client.Preconditions{UID: &k8sSecret.UID}); err != nil && !k8serrors.IsNotFound(err) {
+			return nil, nil, errors.WithMessagef(err, "failed deleting k8s secret %s to change its type", K8SSecretName)
+		}
+		if err = r.createK8SSecret(ctx, secret); err != nil {
+			return nil, nil, errors.WithMessagef(err, "failed recreating K8S Secret %s", K8SSecretName)
+		}
+		if err = r.pruneImmutableK8SSecrets(ctx, cs, secret.Name, ownerAnnotation, owner, log); err != nil {
+			return nil, nil, err
+		}
+		return secret, sourceVersions, nil
+	}
 
-	return ctrl.Result{}, nil
+	// Update the K8S Secret if it already exists
+	if err = r.updateK8SSecret(ctx, secret); err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed updating k8s secret %s", K8SSecretName)
+	}
+	if !k8ssecret.K8SSecretsEqual(k8sSecret, *secret) {
+		log.Info("updated secret", "K8SSecret", secret.ObjectMeta, "secretSize", k8ssecret.SecretLength(secret))
+	}
+	if err = r.pruneImmutableK8SSecrets(ctx, cs, secret.Name, ownerAnnotation, owner, log); err != nil {
+		return nil, nil, err
+	}
+
+	return secret, sourceVersions, nil
+}
+
+// isLegacySecret returns true if secret could have been written by a SyncedSecret before Secrets were annotated with
+// their owner: it is not annotated and has the name and namespace of the SyncedSecret, where the Secret was written
+func isLegacySecret(cs *secretsv1.SyncedSecret, ownerAnnotation string, secret *corev1.Secret) bool {
+	if ownerAnnotation != k8ssecret.AnnotationSyncedSecret {
+		return false
+	}
+	if _, ok := secret.Annotations[ownerAnnotation]; ok {
+		return false
+	}
+	return secret.Name == cs.Name && secret.Namespace == cs.Namespace
+}
+
+// isCurrentSecret returns true if secret is the Secret written by the last successful sync of the SyncedSecret
+func isCurrentSecret(cs *secretsv1.SyncedSecret, secret *corev1.Secret) bool {
+	namespace := cs.Status.CurrentSecretNamespace
+	// the namespace was not recorded by previous versions of kube-secret-syncer
+	if namespace == "" {
+		namespace = k8ssecret.SecretName(*cs).Namespace
+	}
+	return cs.Status.CurrentSecretName != "" && secret.Name == cs.Status.CurrentSecretName && secret.Namespace == namespace
 }
 
-func (r *SyncedSecretReconciler) secretAllowedInNamespace(secretID string, IAMRole string, namespace string, name string) (bool, error) {
+func (r *SyncedSecretReconciler) secretAllowedInNamespace(secretID string, IAMRole string, namespace string) error {
 	log := r.Log.WithValues(LogFieldSyncedSecret, namespace)
 	secret, err := r.poller.DescribeSecret(aws.String(secretID), IAMRole)
 	if err != nil {
 		log.Error(err, "failed to describe secret", "role", IAMRole, "namespace", namespace)
-		return false, errors.WithMessagef(err, "failed to fetch secret %s with role %s in namespace %s", secretID, IAMRole, namespace)
+		err = errors.WithMessagef(err, "failed to fetch secret %s with role %s in namespace %s", secretID, IAMRole, namespace)
+		if isSourceNotFound(err) {
+			return withReason(secretsv1.ReasonSourceNotFound, err)
+		}
+		return err
 	}
 
 	allowed, err := r.NamespaceValidator.HasNamespaceType(secret, namespace)
 	if !allowed {
-		r.sync_state[name] = false
 		log.Error(err, "namespace not allowed in secret", "namespace", namespace, "secret", secretID)
-		return false, errors.WithMessagef(err, "namespace %s not allowed in secret %s", namespace, secretID)
+		return withReason(secretsv1.ReasonNamespaceNotAllowed, errors.Errorf("namespace %s not allowed in secret %s", namespace, secretID))
 	}
 	if err != nil {
-		r.sync_state[name] = false
 		log.Error(err, "failed verifying if namespace is allowed in secret", "namespace", namespace, "secret", secretID)
-		return false, errors.WithMessagef(err, "failed verifying secret %s: %s", secretID, err)
+		return errors.WithMessagef(err, "failed verifying secret %s", secretID)
 	}
-	return true, nil
+	return nil
 }
 
-func (r *SyncedSecretReconciler) templateSecretGetter(secretID string, IAMRole string) (string, error) {
-	secretString, _, err := r.poller.GetSecret(aws.String(secretID), IAMRole)
+// getSecretValue returns the value and version ID of a version of a secret stored in Secrets Manager, read from
+// Secrets Manager rather than the cache if the secrets were polled longer than maxAge ago
+func (r *SyncedSecretReconciler) getSecretValue(ctx context.Context, secretID string, IAMRole string, version secretsmanager.SecretVersion, maxAge time.Duration) (string, string, error) {
+	secretString, versionID, err := r.poller.GetSecretVersion(ctx, aws.String(secretID), IAMRole, version, maxAge)
 	if err != nil {
-		return "", errors.WithMessage(err, fmt.Sprintf("error retrieving secret %s", secretID))
+		err = errors.WithMessage(err, fmt.Sprintf("error retrieving secret %s", secretID))
+		if isSourceNotFound(err) {
+			return "", "", withReason(secretsv1.ReasonSourceNotFound, err)
+		}
+		return "", "", err
 	}
 
-	return secretString, err
+	return secretString, versionID, nil
 }
 
-// createSecret creates a k8s Secret from a SyncedSecret
-func (r *SyncedSecretReconciler) createK8SSecret(ctx context.Context, cs *secretsv1.SyncedSecret) (*corev1.Secret, error) {
+// generateK8SSecret generates the k8s Secret for a SyncedSecret. It also returns the version of each Secrets Manager
+// secret read while generating it.
+func (r *SyncedSecretReconciler) generateK8SSecret(ctx context.Context, cs *secretsv1.SyncedSecret) (*corev1.Secret, map[string]string, error) {
+	tplContext, err := r.templateContext(ctx, cs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sourceVersions := map[string]string{}
+	maxAge := r.refreshInterval(&cs.Spec)
+	secretValueGetter := func(ctx context.Context, secretID string, IAMRole string, version secretsmanager.SecretVersion) (string, error) {
+		secretString, versionID, err := r.getSecretValue(ctx, secretID, IAMRole, version, maxAge)
+		if err != nil {
+			return "", err
+		}
+		// templates still running after their timeout read secrets with a cancelled context, discard what they read
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		sourceVersions[secretID] = versionID
+		return secretString, nil
+	}
 
-	secret, err := k8ssecret.GenerateK8SSecret(*cs, r.poller.PolledSecrets, r.templateSecretGetter, secretsmanager.FilterByTagKey, r.Log)
+	secret, err := k8ssecret.GenerateK8SSecret(ctx, *cs, r.poller.PolledSecrets(), tplContext, secretValueGetter, secretsmanager.FilterByTagKey, r.Log)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	if err = r.Create(ctx, secret); err != nil {
-		return nil, err
+	return secret, sourceVersions, nil
+}
+
+// templateContext returns the SecretTemplates, target namespace and cluster variables available to the templated
+// fields of a SyncedSecret. It is left empty if the SyncedSecret has none.
+func (r *SyncedSecretReconciler) templateContext(ctx context.Context, cs *secretsv1.SyncedSecret) (k8ssecret.TemplateContext, error) {
+	tplContext := k8ssecret.TemplateContext{Limits: r.TemplateLimits}
+	if !k8ssecret.UsesTemplates(cs.Spec) {
+		return tplContext, nil
 	}
 
-	r.Log.Info("Created K8S Secret", "K8SSecret", secret.ObjectMeta, "secretSize", k8ssecret.SecretLength(secret))
+	var secretTemplates secretsv1.SecretTemplateList
+	if err := r.List(ctx, &secretTemplates); err != nil {
+		return tplContext, errors.WithMessage(err, "failed listing SecretTemplates")
+	}
+	tplContext.SecretTemplates = make(map[string]string, len(secretTemplates.Items))
+	for _, secretTemplate := range secretTemplates.Items {
+		tplContext.SecretTemplates[secretTemplate.Name] = secretTemplate.Spec.Template
+	}
 
-	return secret, nil
+	if r.Namespaces != nil {
+		namespace := k8ssecret.SecretName(*cs).Namespace
+		ns, err := r.Namespaces.Get(namespace)
+		if err != nil {
+			return tplContext, errors.WithMessagef(err, "failed getting namespace %s", namespace)
+		}
+		tplContext.Namespace = ns
+	}
+	tplContext.ClusterVariables = r.ClusterVariables
+
+	return tplContext, nil
 }
 
-func (r *SyncedSecretReconciler) updateK8SSecret(ctx context.Context, cs *secretsv1.SyncedSecret) (*corev1.Secret, error) {
-	var secret *corev1.Secret
-	var err error
+// syncedSecretsForTemplate enqueues the SyncedSecrets with templated fields when a SecretTemplate changes, as
+// templates can include each other
+func (r *SyncedSecretReconciler) syncedSecretsForTemplate(ctx context.Context, _ client.Object) []reconcile.Request {
+	var syncedSecrets secretsv1.SyncedSecretList
+	if err := r.List(ctx, &syncedSecrets); err != nil {
+		r.Log.Error(err, "failed listing SyncedSecrets")
+		return nil
+	}
 
-	secret, err = k8ssecret.GenerateK8SSecret(*cs, r.poller.PolledSecrets, r.templateSecretGetter, secretsmanager.FilterByTagKey, r.Log)
-	if err != nil {
-		return nil, err
+	requests := []reconcile.Request{}
+	for _, cs := range syncedSecrets.Items {
+		if k8ssecret.UsesTemplates(cs.Spec) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: cs.Name, Namespace: cs.Namespace}})
+		}
 	}
 
-	if err = r.Update(ctx, secret); err != nil {
-		return nil, err
+	return requests
+}
+
+// createK8SSecret creates a k8s Secret generated from a SyncedSecret
+func (r *SyncedSecretReconciler) createK8SSecret(ctx context.Context, secret *corev1.Secret) error {
+	if err := r.Create(ctx, secret); err != nil {
+		return err
 	}
 
-	return secret, nil
+	r.Log.Info("Created K8S Secret", "K8SSecret", secret.ObjectMeta, "secretSize", k8ssecret.SecretLength(secret))
+
+	return nil
+}
+
+func (r *SyncedSecretReconciler) updateK8SSecret(ctx context.Context, secret *corev1.Secret) error {
+	return r.Update(ctx, secret)
 }
 
-// updateCSStatus updates the SyncedSecret.Status versionId (from aws SSM) seen
+// updateCSStatus updates the SyncedSecret.Status
 func (r *SyncedSecretReconciler) updateCSStatus(ctx context.Context, cs *secretsv1.SyncedSecret) error {
-	//cs.Status.CurrentVersionID = r.poller.PolledSecrets[cs.Spec.SecretID].CurrentVersionID
 	return r.Status().Update(ctx, cs)
 }
 
+// syncedSecretChanged filters out updates of a SyncedSecret that only touch its status, as writing the status would
+// otherwise trigger another reconcile. Periodic resyncs, where the object is unchanged, still go through.
+func syncedSecretChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld == nil || e.ObjectNew == nil {
+				return true
+			}
+			return e.ObjectOld.GetResourceVersion() == e.ObjectNew.GetResourceVersion() ||
+				e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration() ||
+				!e.ObjectNew.GetDeletionTimestamp().IsZero() ||
+				!reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) ||
+				!reflect.DeepEqual(e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations())
+		},
+	}
+}
+
 func (r *SyncedSecretReconciler) Quit() {
 	r.poller.Stop()
 	r.wg.Wait()
@@ -303,7 +893,8 @@ func (r *SyncedSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&secretsv1.SyncedSecret{}).
+		For(&secretsv1.SyncedSecret{}, builder.WithPredicates(syncedSecretChanged())).
+		Watches(&secretsv1.SecretTemplate{}, handler.EnqueueRequestsFromMapFunc(r.syncedSecretsForTemplate)).
 		Complete(r)
 }
 
@@ -11,8 +11,10 @@ import (
 	. "github.com/onsi/gomega"
 
 	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	"github.com/contentful-labs/kube-secret-syncer/pkg/k8ssecret"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -593,4 +595,600 @@ var _ = Describe("SyncedSecret Controller", func() {
 			}, timeout, interval).Should(BeTrue())
 		})
 	})
+
+	Context("For a SyncedSecret with a refresh interval", func() {
+		r := &SyncedSecretReconciler{MinRefreshInterval: 30 * time.Second}
+
+		It("Should enforce the minimum refresh interval", func() {
+			Expect(r.refreshInterval(&secretsv1.SyncedSecretSpec{})).To(Equal(time.Duration(0)))
+			Expect(r.refreshInterval(&secretsv1.SyncedSecretSpec{RefreshInterval: &metav1.Duration{Duration: time.Second}})).To(Equal(30 * time.Second))
+			Expect(r.refreshInterval(&secretsv1.SyncedSecretSpec{RefreshInterval: &metav1.Duration{Duration: time.Hour}})).To(Equal(time.Hour))
+		})
+
+		It("Should not refresh suspended SyncedSecrets", func() {
+			Expect(r.refreshInterval(&secretsv1.SyncedSecretSpec{Suspend: true, RefreshInterval: &metav1.Duration{Duration: time.Hour}})).To(Equal(time.Duration(0)))
+		})
+	})
+
+	Context("For a SyncedSecret writing to an existing K8S Secret", func() {
+		existingSecret := func(name string) *corev1.Secret {
+			return &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: TEST_NAMESPACE,
+					Labels:    map[string]string{"app": "other-tool"},
+				},
+				Data: map[string][]byte{
+					"OTHER_KEY": []byte("other"),
+				},
+			}
+		}
+		syncedSecret := func(name string, creationPolicy secretsv1.CreationPolicy) *secretsv1.SyncedSecret {
+			return &secretsv1.SyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: TEST_NAMESPACE,
+				},
+				Spec: secretsv1.SyncedSecretSpec{
+					IAMRole:        _s("test"),
+					CreationPolicy: creationPolicy,
+					Data: []*secretsv1.SecretField{
+						{
+							Name:  _s("DB_NAME"),
+							Value: _s("secretDB"),
+						},
+					},
+				},
+			}
+		}
+
+		It("Should not take over an unmanaged K8S Secret until it is annotated", func() {
+			secretKey := types.NamespacedName{Name: "unmanaged-secret", Namespace: TEST_NAMESPACE}
+			cfSecretKey := types.NamespacedName{Name: "unmanaged-secret-syncer", Namespace: TEST_NAMESPACE}
+			Expect(k8sClient.Create(context.Background(), existingSecret(secretKey.Name))).Should(Succeed())
+			toCreate := syncedSecret(cfSecretKey.Name, "")
+			toCreate.Spec.SecretMetadata.Name = secretKey.Name
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Eventually(func() string {
+				k8sClient.Get(context.Background(), cfSecretKey, fetchedCfSecret)
+				condition := meta.FindStatusCondition(fetchedCfSecret.Status.Conditions, secretsv1.ConditionTypeReady)
+				if condition == nil || condition.Status != metav1.ConditionFalse {
+					return ""
+				}
+				return condition.Reason
+			}, timeout, interval).Should(Equal(secretsv1.ReasonSecretConflict))
+
+			fetchedSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(context.Background(), secretKey, fetchedSecret)).Should(Succeed())
+			Expect(fetchedSecret.Data).To(Equal(map[string][]byte{"OTHER_KEY": []byte("other")}))
+
+			fetchedSecret.Annotations = map[string]string{k8ssecret.AnnotationSyncedSecret: cfSecretKey.String()}
+			Expect(k8sClient.Update(context.Background(), fetchedSecret)).Should(Succeed())
+
+			Eventually(func() map[string][]byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data
+			}, timeout, interval).Should(Equal(map[string][]byte{"DB_NAME": []byte("secretDB")}))
+			Expect(fetchedSecret.Labels[k8ssecret.LabelManagedBy]).To(Equal(k8ssecret.ManagedBy))
+		})
+
+		It("Should take over a K8S Secret written by an earlier version at the name of the SyncedSecret", func() {
+			secretKey := types.NamespacedName{Name: "legacy-secret", Namespace: TEST_NAMESPACE}
+			legacySecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: secretKey.Name, Namespace: secretKey.Namespace},
+				Data:       map[string][]byte{"DB_NAME": []byte("oldDB")},
+			}
+			Expect(k8sClient.Create(context.Background(), legacySecret)).Should(Succeed())
+			Expect(k8sClient.Create(context.Background(), syncedSecret(secretKey.Name, ""))).Should(Succeed())
+
+			fetchedSecret := &corev1.Secret{}
+			Eventually(func() map[string][]byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data
+			}, timeout, interval).Should(Equal(map[string][]byte{"DB_NAME": []byte("secretDB")}))
+			Expect(fetchedSecret.Annotations[k8ssecret.AnnotationSyncedSecret]).To(Equal(secretKey.String()))
+			Expect(fetchedSecret.Labels[k8ssecret.LabelManagedBy]).To(Equal(k8ssecret.ManagedBy))
+		})
+
+		It("Should merge the synced keys into the K8S Secret with the Merge creation policy", func() {
+			secretKey := types.NamespacedName{Name: "merged-secret", Namespace: TEST_NAMESPACE}
+			Expect(k8sClient.Create(context.Background(), existingSecret(secretKey.Name))).Should(Succeed())
+			Expect(k8sClient.Create(context.Background(), syncedSecret(secretKey.Name, secretsv1.CreationPolicyMerge))).Should(Succeed())
+
+			fetchedSecret := &corev1.Secret{}
+			Eventually(func() map[string][]byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data
+			}, timeout, interval).Should(Equal(map[string][]byte{"OTHER_KEY": []byte("other"), "DB_NAME": []byte("secretDB")}))
+			Expect(fetchedSecret.Labels).To(Equal(map[string]string{"app": "other-tool"}))
+		})
+
+		It("Should not change the type of the K8S Secret with the Merge creation policy", func() {
+			secretKey := types.NamespacedName{Name: "merged-typed-secret", Namespace: TEST_NAMESPACE}
+			Expect(k8sClient.Create(context.Background(), existingSecret(secretKey.Name))).Should(Succeed())
+			toCreate := syncedSecret(secretKey.Name, secretsv1.CreationPolicyMerge)
+			toCreate.Spec.SecretMetadata.Type = corev1.SecretTypeBasicAuth
+			toCreate.Spec.Data[0].Name = _s(corev1.BasicAuthUsernameKey)
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Eventually(func() string {
+				k8sClient.Get(context.Background(), secretKey, fetchedCfSecret)
+				condition := meta.FindStatusCondition(fetchedCfSecret.Status.Conditions, secretsv1.ConditionTypeReady)
+				if condition == nil || condition.Status != metav1.ConditionFalse {
+					return ""
+				}
+				return condition.Reason
+			}, timeout, interval).Should(Equal(secretsv1.ReasonSecretTypeMismatch))
+
+			fetchedSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(context.Background(), secretKey, fetchedSecret)).Should(Succeed())
+			Expect(fetchedSecret.Type).To(Equal(corev1.SecretTypeOpaque))
+			Expect(fetchedSecret.Data).To(Equal(map[string][]byte{"OTHER_KEY": []byte("other")}))
+		})
+
+		It("Should not overwrite an existing K8S Secret with the Orphan creation policy", func() {
+			secretKey := types.NamespacedName{Name: "orphaned-secret", Namespace: TEST_NAMESPACE}
+			Expect(k8sClient.Create(context.Background(), existingSecret(secretKey.Name))).Should(Succeed())
+			Expect(k8sClient.Create(context.Background(), syncedSecret(secretKey.Name, secretsv1.CreationPolicyOrphan))).Should(Succeed())
+
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Eventually(func() string {
+				k8sClient.Get(context.Background(), secretKey, fetchedCfSecret)
+				condition := meta.FindStatusCondition(fetchedCfSecret.Status.Conditions, secretsv1.ConditionTypeReady)
+				if condition == nil || condition.Status != metav1.ConditionFalse {
+					return ""
+				}
+				return condition.Reason
+			}, timeout, interval).Should(Equal(secretsv1.ReasonSecretConflict))
+
+			fetchedSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(context.Background(), secretKey, fetchedSecret)).Should(Succeed())
+			Expect(fetchedSecret.Data).To(Equal(map[string][]byte{"OTHER_KEY": []byte("other")}))
+		})
+
+		It("Should keep the K8S Secret it created up to date with the Orphan creation policy", func() {
+			secretKey := types.NamespacedName{Name: "orphan-created-secret", Namespace: TEST_NAMESPACE}
+			Expect(k8sClient.Create(context.Background(), syncedSecret(secretKey.Name, secretsv1.CreationPolicyOrphan))).Should(Succeed())
+
+			fetchedSecret := &corev1.Secret{}
+			Eventually(func() map[string][]byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data
+			}, timeout, interval).Should(Equal(map[string][]byte{"DB_NAME": []byte("secretDB")}))
+			Expect(fetchedSecret.Annotations).ToNot(HaveKey(k8ssecret.AnnotationSyncedSecret))
+
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Eventually(func() string {
+				k8sClient.Get(context.Background(), secretKey, fetchedCfSecret)
+				return fetchedCfSecret.Status.CurrentSecretName
+			}, timeout, interval).Should(Equal(secretKey.Name))
+			fetchedCfSecret.Spec.Data[0].Value = _s("otherDB")
+			Expect(k8sClient.Update(context.Background(), fetchedCfSecret)).Should(Succeed())
+
+			Eventually(func() map[string][]byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data
+			}, timeout, interval).Should(Equal(map[string][]byte{"DB_NAME": []byte("otherDB")}))
+		})
+
+		It("Should recreate an owned K8S Secret whose type changed", func() {
+			secretKey := types.NamespacedName{Name: "retyped-secret", Namespace: TEST_NAMESPACE}
+			toCreate := syncedSecret(secretKey.Name, "")
+			toCreate.Spec.Data[0].Name = _s(corev1.BasicAuthUsernameKey)
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			fetchedSecret := &corev1.Secret{}
+			Eventually(func() error {
+				return k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+			}, timeout, interval).Should(Succeed())
+			Expect(fetchedSecret.Type).To(Equal(corev1.SecretTypeOpaque))
+
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Expect(k8sClient.Get(context.Background(), secretKey, fetchedCfSecret)).Should(Succeed())
+			fetchedCfSecret.Spec.SecretMetadata.Type = corev1.SecretTypeBasicAuth
+			Expect(k8sClient.Update(context.Background(), fetchedCfSecret)).Should(Succeed())
+
+			Eventually(func() corev1.SecretType {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Type
+			}, timeout, interval).Should(Equal(corev1.SecretTypeBasicAuth))
+			Expect(fetchedSecret.Data).To(Equal(map[string][]byte{corev1.BasicAuthUsernameKey: []byte("secretDB")}))
+		})
+	})
+
+	Context("For a deleted SyncedSecret", func() {
+		syncedSecret := func(name string, deletionPolicy secretsv1.DeletionPolicy) *secretsv1.SyncedSecret {
+			return &secretsv1.SyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: TEST_NAMESPACE,
+				},
+				Spec: secretsv1.SyncedSecretSpec{
+					IAMRole:        _s("test"),
+					DeletionPolicy: deletionPolicy,
+					Data: []*secretsv1.SecretField{
+						{
+							Name:  _s("DB_NAME"),
+							Value: _s("secretDB"),
+						},
+					},
+				},
+			}
+		}
+
+		It("Should delete the K8S Secret by default", func() {
+			toCreate := syncedSecret("deleted-secret", "")
+			secretKey := types.NamespacedName{Name: toCreate.Name, Namespace: toCreate.Namespace}
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), secretKey, &corev1.Secret{})
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeFalse())
+
+			Expect(k8sClient.Delete(context.Background(), toCreate)).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), secretKey, &corev1.Secret{})
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), secretKey, &secretsv1.SyncedSecret{})
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("Should keep the K8S Secret with the Retain deletion policy", func() {
+			toCreate := syncedSecret("retained-secret", secretsv1.DeletionPolicyRetain)
+			secretKey := types.NamespacedName{Name: toCreate.Name, Namespace: toCreate.Namespace}
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), secretKey, &corev1.Secret{})
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeFalse())
+
+			Expect(k8sClient.Delete(context.Background(), toCreate)).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), secretKey, &secretsv1.SyncedSecret{})
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+			fetchedSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(context.Background(), secretKey, fetchedSecret)).Should(Succeed())
+			Expect(fetchedSecret.Data).To(Equal(map[string][]byte{"DB_NAME": []byte("secretDB")}))
+		})
+
+		It("Should delete the previous K8S Secret when the target changes", func() {
+			toCreate := syncedSecret("moved-secret", "")
+			previousKey := types.NamespacedName{Name: toCreate.Name, Namespace: toCreate.Namespace}
+			secretKey := types.NamespacedName{Name: "moved-secret-target", Namespace: TEST_NAMESPACE2}
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), previousKey, &corev1.Secret{})
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeFalse())
+
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Expect(k8sClient.Get(context.Background(), previousKey, fetchedCfSecret)).Should(Succeed())
+			fetchedCfSecret.Spec.SecretMetadata = secretsv1.SecretMetadata{
+				Name:      secretKey.Name,
+				Namespace: secretKey.Namespace,
+			}
+			Expect(k8sClient.Update(context.Background(), fetchedCfSecret)).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), secretKey, &corev1.Secret{})
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeFalse())
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), previousKey, &corev1.Secret{})
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+
+			Eventually(func() string {
+				k8sClient.Get(context.Background(), previousKey, fetchedCfSecret)
+				return fetchedCfSecret.Status.CurrentSecretNamespace
+			}, timeout, interval).Should(Equal(secretKey.Namespace))
+			Expect(fetchedCfSecret.Status.CurrentSecretName).To(Equal(secretKey.Name))
+		})
+	})
+
+	Context("For an immutable SyncedSecret", func() {
+		cfSecretKey := types.NamespacedName{Name: "immutable-secret", Namespace: TEST_NAMESPACE}
+
+		currentSecretName := func() string {
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			k8sClient.Get(context.Background(), cfSecretKey, fetchedCfSecret)
+			return fetchedCfSecret.Status.CurrentSecretName
+		}
+		setValue := func(value string) {
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Expect(k8sClient.Get(context.Background(), cfSecretKey, fetchedCfSecret)).Should(Succeed())
+			fetchedCfSecret.Spec.Data[0].Value = _s(value)
+			Expect(k8sClient.Update(context.Background(), fetchedCfSecret)).Should(Succeed())
+		}
+
+		It("Should write a new immutable K8S Secret for every version and prune the old ones", func() {
+			toCreate := &secretsv1.SyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cfSecretKey.Name,
+					Namespace: cfSecretKey.Namespace,
+				},
+				Spec: secretsv1.SyncedSecretSpec{
+					IAMRole:               _s("test"),
+					Immutable:             true,
+					ImmutableHistoryLimit: &[]int32{1}[0],
+					Data: []*secretsv1.SecretField{
+						{
+							Name:  _s("DB_NAME"),
+							Value: _s("v1"),
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			Eventually(currentSecretName, timeout, interval).Should(HavePrefix(cfSecretKey.Name + "-"))
+			firstName := currentSecretName()
+
+			fetchedSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: firstName, Namespace: TEST_NAMESPACE}, fetchedSecret)).Should(Succeed())
+			Expect(fetchedSecret.Immutable).ToNot(BeNil())
+			Expect(*fetchedSecret.Immutable).To(BeTrue())
+			Expect(fetchedSecret.Data).To(Equal(map[string][]byte{"DB_NAME": []byte("v1")}))
+
+			setValue("v2")
+			Eventually(currentSecretName, timeout, interval).ShouldNot(Equal(firstName))
+			secondName := currentSecretName()
+			Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: firstName, Namespace: TEST_NAMESPACE}, &corev1.Secret{})).Should(Succeed())
+
+			setValue("v3")
+			Eventually(currentSecretName, timeout, interval).ShouldNot(Equal(secondName))
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), types.NamespacedName{Name: firstName, Namespace: TEST_NAMESPACE}, &corev1.Secret{})
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+			Expect(k8sClient.Get(context.Background(), types.NamespacedName{Name: secondName, Namespace: TEST_NAMESPACE}, &corev1.Secret{})).Should(Succeed())
+		})
+	})
+
+	Context("For a suspended SyncedSecret", func() {
+		secretKey := types.NamespacedName{Name: "suspended-secret", Namespace: TEST_NAMESPACE}
+
+		It("Should leave the K8S Secret as it is and report the suspension", func() {
+			toCreate := &secretsv1.SyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      secretKey.Name,
+					Namespace: secretKey.Namespace,
+				},
+				Spec: secretsv1.SyncedSecretSpec{
+					IAMRole: _s("test"),
+					Data: []*secretsv1.SecretField{
+						{
+							Name:  _s("DB_NAME"),
+							Value: _s("v1"),
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			fetchedSecret := &corev1.Secret{}
+			Eventually(func() map[string][]byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data
+			}, timeout, interval).Should(Equal(map[string][]byte{"DB_NAME": []byte("v1")}))
+
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Expect(k8sClient.Get(context.Background(), secretKey, fetchedCfSecret)).Should(Succeed())
+			fetchedCfSecret.Spec.Suspend = true
+			fetchedCfSecret.Spec.Data[0].Value = _s("v2")
+			Expect(k8sClient.Update(context.Background(), fetchedCfSecret)).Should(Succeed())
+
+			Eventually(func() string {
+				k8sClient.Get(context.Background(), secretKey, fetchedCfSecret)
+				condition := meta.FindStatusCondition(fetchedCfSecret.Status.Conditions, secretsv1.ConditionTypeReady)
+				if condition == nil {
+					return ""
+				}
+				return condition.Reason
+			}, timeout, interval).Should(Equal(secretsv1.ReasonSuspended))
+			Consistently(func() map[string][]byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data
+			}, time.Second, interval).Should(Equal(map[string][]byte{"DB_NAME": []byte("v1")}))
+
+			fetchedCfSecret.Spec.Suspend = false
+			Expect(k8sClient.Update(context.Background(), fetchedCfSecret)).Should(Succeed())
+			Eventually(func() map[string][]byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data
+			}, timeout, interval).Should(Equal(map[string][]byte{"DB_NAME": []byte("v2")}))
+		})
+	})
+
+	Context("For a SyncedSecret referencing a missing key", func() {
+		secretKey := types.NamespacedName{
+			Name:      "missing-key-secret",
+			Namespace: TEST_NAMESPACE,
+		}
+
+		It("Should leave the K8S Secret untouched and report the missing key", func() {
+			toCreate := &secretsv1.SyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      secretKey.Name,
+					Namespace: secretKey.Namespace,
+				},
+				Spec: secretsv1.SyncedSecretSpec{
+					IAMRole: _s("test"),
+					Data: []*secretsv1.SecretField{
+						{
+							Name: _s("DB_NAME"),
+							ValueFrom: &secretsv1.ValueFrom{
+								SecretKeyRef: &secretsv1.SecretKeyRef{
+									Name: _s("random/aws/secret003"),
+									Key:  _s("database_name"),
+								},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			fetchedSecret := &corev1.Secret{}
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeFalse())
+
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Expect(k8sClient.Get(context.Background(), secretKey, fetchedCfSecret)).Should(Succeed())
+			fetchedCfSecret.Spec.Data[0].ValueFrom.SecretKeyRef.Key = _s("database_user")
+			Expect(k8sClient.Update(context.Background(), fetchedCfSecret)).Should(Succeed())
+
+			Eventually(func() string {
+				k8sClient.Get(context.Background(), secretKey, fetchedCfSecret)
+				condition := meta.FindStatusCondition(fetchedCfSecret.Status.Conditions, secretsv1.ConditionTypeReady)
+				if condition == nil || condition.Status != metav1.ConditionFalse {
+					return ""
+				}
+				return condition.Reason
+			}, timeout, interval).Should(Equal(secretsv1.ReasonKeyNotFound))
+			Expect(meta.FindStatusCondition(fetchedCfSecret.Status.Conditions, secretsv1.ConditionTypeReady).Message).To(ContainSubstring("database_user"))
+
+			Expect(k8sClient.Get(context.Background(), secretKey, fetchedSecret)).Should(Succeed())
+			Expect(fetchedSecret.Data).To(Equal(map[string][]byte{"DB_NAME": []byte("secretDB")}))
+		})
+	})
+
+	Context("For a SyncedSecret with a target name and namespace", func() {
+		syncedSecretKey := types.NamespacedName{
+			Name:      "renamed-secret",
+			Namespace: TEST_NAMESPACE,
+		}
+		secretKey := types.NamespacedName{
+			Name:      "legacy.secret.name",
+			Namespace: TEST_NAMESPACE2,
+		}
+
+		It("Should create the K8S Secret with the name and namespace from the secret metadata", func() {
+			toCreate := &secretsv1.SyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      syncedSecretKey.Name,
+					Namespace: syncedSecretKey.Namespace,
+				},
+				Spec: secretsv1.SyncedSecretSpec{
+					SecretMetadata: secretsv1.SecretMetadata{
+						Name:      secretKey.Name,
+						Namespace: secretKey.Namespace,
+					},
+					IAMRole: _s("test"),
+					Data: []*secretsv1.SecretField{
+						{
+							Name:  _s("DB_NAME"),
+							Value: _s("secretDB"),
+						},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			fetchedSecret := &corev1.Secret{}
+			Eventually(func() bool {
+				err := k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return k8serrors.IsNotFound(err)
+			}, timeout, interval).Should(BeFalse())
+
+			Expect(fetchedSecret.Data).To(Equal(map[string][]byte{"DB_NAME": []byte("secretDB")}))
+			Expect(fetchedSecret.Annotations[k8ssecret.AnnotationSyncedSecret]).To(Equal(syncedSecretKey.String()))
+
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Eventually(func() bool {
+				k8sClient.Get(context.Background(), syncedSecretKey, fetchedCfSecret)
+				return meta.IsStatusConditionTrue(fetchedCfSecret.Status.Conditions, secretsv1.ConditionTypeReady)
+			}, timeout, interval).Should(BeTrue())
+			Expect(fetchedCfSecret.Status.ObservedGeneration).To(Equal(fetchedCfSecret.Generation))
+			Expect(fetchedCfSecret.Status.LastSyncTime).ToNot(BeNil())
+			Expect(fetchedCfSecret.Status.SecretHash).To(Equal(k8ssecret.SecretHash(fetchedSecret)))
+		})
+
+		It("Should not overwrite a K8S Secret managed by another SyncedSecret", func() {
+			toCreate := &secretsv1.SyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "conflicting-secret",
+					Namespace: TEST_NAMESPACE,
+				},
+				Spec: secretsv1.SyncedSecretSpec{
+					SecretMetadata: secretsv1.SecretMetadata{
+						Name:      secretKey.Name,
+						Namespace: secretKey.Namespace,
+					},
+					IAMRole: _s("test"),
+					Data: []*secretsv1.SecretField{
+						{
+							Name:  _s("DB_NAME"),
+							Value: _s("otherDB"),
+						},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			fetchedSecret := &corev1.Secret{}
+			Consistently(func() []byte {
+				k8sClient.Get(context.Background(), secretKey, fetchedSecret)
+				return fetchedSecret.Data["DB_NAME"]
+			}, 10*time.Second, interval).Should(Equal([]byte("secretDB")))
+		})
+
+		It("Should not write K8S Secrets in namespaces that do not allow it", func() {
+			forbiddenKey := types.NamespacedName{
+				Name:      "forbidden-secret",
+				Namespace: TEST_NAMESPACE3,
+			}
+			toCreate := &secretsv1.SyncedSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      forbiddenKey.Name,
+					Namespace: TEST_NAMESPACE,
+				},
+				Spec: secretsv1.SyncedSecretSpec{
+					SecretMetadata: secretsv1.SecretMetadata{
+						Namespace: forbiddenKey.Namespace,
+					},
+					IAMRole: _s("test"),
+					Data: []*secretsv1.SecretField{
+						{
+							Name:  _s("DB_NAME"),
+							Value: _s("secretDB"),
+						},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(context.Background(), toCreate)).Should(Succeed())
+
+			Consistently(func() bool {
+				err := k8sClient.Get(context.Background(), forbiddenKey, &corev1.Secret{})
+				return k8serrors.IsNotFound(err)
+			}, 10*time.Second, interval).Should(BeTrue())
+
+			fetchedCfSecret := &secretsv1.SyncedSecret{}
+			Eventually(func() string {
+				k8sClient.Get(context.Background(), types.NamespacedName{Name: forbiddenKey.Name, Namespace: TEST_NAMESPACE}, fetchedCfSecret)
+				condition := meta.FindStatusCondition(fetchedCfSecret.Status.Conditions, secretsv1.ConditionTypeReady)
+				if condition == nil || condition.Status != metav1.ConditionFalse {
+					return ""
+				}
+				return condition.Reason
+			}, timeout, interval).Should(Equal(secretsv1.ReasonNamespaceNotAllowed))
+		})
+	})
 })
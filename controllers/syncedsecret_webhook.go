@@ -0,0 +1,245 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	"github.com/contentful-labs/kube-secret-syncer/pkg/k8ssecret"
+)
+
+// +kubebuilder:webhook:path=/validate-secrets-contentful-com-v1-syncedsecret,mutating=false,failurePolicy=fail,sideEffects=None,groups=secrets.contentful.com,resources=syncedsecrets,verbs=create;update,versions=v1,name=vsyncedsecret.secrets.contentful.com,admissionReviewVersions=v1
+
+// SyncedSecretValidator rejects malformed SyncedSecrets at admission time, instead of failing their sync
+type SyncedSecretValidator struct {
+	RoleValidator RoleValidator
+	Log           logr.Logger
+}
+
+var _ admission.CustomValidator = &SyncedSecretValidator{}
+
+// ValidateCreate validates a new SyncedSecret
+func (v *SyncedSecretValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	cs, ok := obj.(*secretsv1.SyncedSecret)
+	if !ok {
+		return nil, fmt.Errorf("expected a SyncedSecret, got %T", obj)
+	}
+	return v.validate(cs)
+}
+
+// ValidateUpdate validates an updated SyncedSecret. SyncedSecrets being deleted and updates leaving the spec
+// unchanged are not validated, so that finalizers can always be added and removed, even on SyncedSecrets created
+// before the webhook rejected them.
+func (v *SyncedSecretValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	cs, ok := newObj.(*secretsv1.SyncedSecret)
+	if !ok {
+		return nil, fmt.Errorf("expected a SyncedSecret, got %T", newObj)
+	}
+	oldCs, ok := oldObj.(*secretsv1.SyncedSecret)
+	if !ok {
+		return nil, fmt.Errorf("expected a SyncedSecret, got %T", oldObj)
+	}
+	if !cs.DeletionTimestamp.IsZero() || equality.Semantic.DeepEqual(oldCs.Spec, cs.Spec) {
+		return nil, nil
+	}
+	return v.validate(cs)
+}
+
+// ValidateDelete allows all deletions
+func (v *SyncedSecretValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *SyncedSecretValidator) validate(cs *secretsv1.SyncedSecret) (admission.Warnings, error) {
+	var warnings admission.Warnings
+	errs := validateSyncedSecretSpec(cs, field.NewPath("spec"))
+
+	// only IAMRole is restricted by the namespace, AWSAccountID takes precedence over it when both are set
+	if cs.Spec.AWSAccountID == nil && cs.Spec.IAMRole != nil {
+		namespace := k8ssecret.SecretName(*cs).Namespace
+		allowed, err := v.RoleValidator.IsWhitelisted(*cs.Spec.IAMRole, namespace)
+		if err != nil {
+			// the role may become allowed later on, its sync then reports the failure
+			v.Log.Error(err, "failed verifying if IAMRole is whitelisted", "role", *cs.Spec.IAMRole, "namespace", namespace)
+			warnings = append(warnings, fmt.Sprintf("could not verify that role %s is allowed in namespace %s: %s", *cs.Spec.IAMRole, namespace, err))
+		} else if !allowed {
+			errs = append(errs, field.Forbidden(field.NewPath("spec", "IAMRole"), fmt.Sprintf("role %s not allowed in namespace %s", *cs.Spec.IAMRole, namespace)))
+		}
+	}
+
+	if len(errs) > 0 {
+		return warnings, k8serrors.NewInvalid(secretsv1.GroupVersion.WithKind("SyncedSecret").GroupKind(), cs.Name, errs)
+	}
+	return warnings, nil
+}
+
+// validateSyncedSecretSpec checks the parts of a SyncedSecret that the CRD schema can not express
+func validateSyncedSecretSpec(cs *secretsv1.SyncedSecret, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if cs.Spec.IAMRole == nil && cs.Spec.AWSAccountID == nil {
+		errs = append(errs, field.Required(path.Child("IAMRole"), "one of IAMRole and AWSAccountID must be set"))
+	}
+
+	if cs.Spec.DataFrom != nil && cs.Spec.DataFrom.SecretRef != nil && cs.Spec.DataFrom.SecretRef.Name == nil {
+		errs = append(errs, field.Required(path.Child("dataFrom", "secretRef", "name"), ""))
+	}
+
+	if cs.Spec.SecretMetadata.TemplateMetadata {
+		errs = append(errs, validateMetadataTemplates(cs, cs.Spec.SecretMetadata.Labels, path.Child("secretMetadata", "labels"))...)
+		errs = append(errs, validateMetadataTemplates(cs, cs.Spec.SecretMetadata.Annotations, path.Child("secretMetadata", "annotations"))...)
+	}
+
+	if propagate := cs.Spec.SecretMetadata.PropagateTags; propagate != nil {
+		propagatePath := path.Child("secretMetadata", "propagateTags")
+		if len(propagate.Tags) == 0 {
+			errs = append(errs, field.Required(propagatePath.Child("tags"), ""))
+		}
+		if propagate.Prefix != "" {
+			for _, msg := range validation.IsDNS1123Subdomain(propagate.Prefix) {
+				errs = append(errs, field.Invalid(propagatePath.Child("prefix"), propagate.Prefix, msg))
+			}
+		}
+	}
+
+	if cs.Spec.TemplateFrom != nil {
+		errs = append(errs, validateTemplateFrom(cs, cs.Spec.TemplateFrom, path.Child("templateFrom"))...)
+	}
+
+	if cs.Spec.Immutable && cs.Spec.CreationPolicy != "" && cs.Spec.CreationPolicy != secretsv1.CreationPolicyOwner {
+		errs = append(errs, field.Invalid(path.Child("creationPolicy"), cs.Spec.CreationPolicy, fmt.Sprintf("immutable Secrets can only be written with the %s creation policy", secretsv1.CreationPolicyOwner)))
+	}
+
+	names := map[string]bool{}
+	for i, secretField := range cs.Spec.Data {
+		fieldPath := path.Child("data").Index(i)
+		if secretField == nil {
+			errs = append(errs, field.Required(fieldPath, ""))
+			continue
+		}
+
+		if secretField.Name == nil || *secretField.Name == "" {
+			errs = append(errs, field.Required(fieldPath.Child("name"), ""))
+		} else if names[*secretField.Name] {
+			errs = append(errs, field.Duplicate(fieldPath.Child("name"), *secretField.Name))
+		} else {
+			names[*secretField.Name] = true
+		}
+
+		if (secretField.Value == nil) == (secretField.ValueFrom == nil) {
+			errs = append(errs, field.Invalid(fieldPath, field.OmitValueType{}, "exactly one of value and valueFrom must be set"))
+		}
+		if secretField.ValueFrom != nil {
+			errs = append(errs, validateValueFrom(cs, secretField.ValueFrom, fieldPath.Child("valueFrom"))...)
+		}
+	}
+
+	return errs
+}
+
+func validateValueFrom(cs *secretsv1.SyncedSecret, valueFrom *secretsv1.ValueFrom, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	sources := 0
+	if valueFrom.SecretRef != nil {
+		sources++
+		if valueFrom.SecretRef.Name == nil {
+			errs = append(errs, field.Required(path.Child("secretRef", "name"), ""))
+		}
+	}
+	if valueFrom.SecretKeyRef != nil {
+		sources++
+		if valueFrom.SecretKeyRef.Name == nil {
+			errs = append(errs, field.Required(path.Child("secretKeyRef", "name"), ""))
+		}
+		if valueFrom.SecretKeyRef.Key == nil {
+			errs = append(errs, field.Required(path.Child("secretKeyRef", "key"), ""))
+		}
+	}
+	if valueFrom.Template != nil {
+		sources++
+		if err := k8ssecret.ParseTemplate(cs.Name, *valueFrom.Template); err != nil {
+			errs = append(errs, field.Invalid(path.Child("template"), field.OmitValueType{}, err.Error()))
+		}
+	}
+	if valueFrom.TemplateRef != nil {
+		sources++
+		if valueFrom.TemplateRef.Name == "" {
+			errs = append(errs, field.Required(path.Child("templateRef", "name"), ""))
+		}
+	}
+	if sources != 1 {
+		errs = append(errs, field.Invalid(path, field.OmitValueType{}, "exactly one of secretRef, secretKeyRef, template and templateRef must be set"))
+	}
+
+	return errs
+}
+
+// validateMetadataTemplates parses the values of templated labels or annotations
+func validateMetadataTemplates(cs *secretsv1.SyncedSecret, values map[string]string, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := k8ssecret.ParseTemplate(cs.Name, values[key]); err != nil {
+			errs = append(errs, field.Invalid(path.Key(key), values[key], err.Error()))
+		}
+	}
+
+	return errs
+}
+
+func validateTemplateFrom(cs *secretsv1.SyncedSecret, templateFrom *secretsv1.TemplateFrom, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if (templateFrom.Template == nil) == (templateFrom.TemplateRef == nil) {
+		errs = append(errs, field.Invalid(path, field.OmitValueType{}, "exactly one of template and templateRef must be set"))
+	}
+	if templateFrom.Template != nil {
+		if err := k8ssecret.ParseTemplate(cs.Name, *templateFrom.Template); err != nil {
+			errs = append(errs, field.Invalid(path.Child("template"), field.OmitValueType{}, err.Error()))
+		}
+	}
+	if templateFrom.TemplateRef != nil && templateFrom.TemplateRef.Name == "" {
+		errs = append(errs, field.Required(path.Child("templateRef", "name"), ""))
+	}
+
+	return errs
+}
+
+// SetupWebhookWithManager registers the validating webhook for SyncedSecrets
+func (v *SyncedSecretValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&secretsv1.SyncedSecret{}).
+		WithValidator(v).
+		Complete()
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+)
+
+// allowedRoleValidator only allows the role "test", and fails for the role "broken"
+type allowedRoleValidator struct{}
+
+func (m *allowedRoleValidator) IsWhitelisted(role, namespace string) (bool, error) {
+	if role == "broken" {
+		return false, fmt.Errorf("failed getting ARN for role %s", role)
+	}
+	return role == "test", nil
+}
+
+var _ = Describe("SyncedSecretValidator", func() {
+	validator := &SyncedSecretValidator{RoleValidator: &allowedRoleValidator{}, Log: logf.Log}
+
+	syncedSecret := func(data ...*secretsv1.SecretField) *secretsv1.SyncedSecret {
+		return &secretsv1.SyncedSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "validated-secret",
+				Namespace: TEST_NAMESPACE,
+			},
+			Spec: secretsv1.SyncedSecretSpec{
+				IAMRole: _s("test"),
+				Data:    data,
+			},
+		}
+	}
+
+	It("Should accept a valid SyncedSecret", func() {
+		cs := syncedSecret(
+			&secretsv1.SecretField{Name: _s("DB_NAME"), Value: _s("secretDB")},
+			&secretsv1.SecretField{Name: _s("DB_PASS"), ValueFrom: &secretsv1.ValueFrom{SecretKeyRef: &secretsv1.SecretKeyRef{Name: _s("random/aws/secret003"), Key: _s("password")}}},
+			&secretsv1.SecretField{Name: _s("DB_URL"), ValueFrom: &secretsv1.ValueFrom{Template: _s(`{{ getSecretValue "random/aws/secret003" }}`)}},
+			&secretsv1.SecretField{Name: _s("DB_DSN"), ValueFrom: &secretsv1.ValueFrom{TemplateRef: &secretsv1.TemplateRef{Name: "dsn", Parameters: map[string]string{"db": "secretDB"}}}},
+			&secretsv1.SecretField{Name: _s("DB_HOST"), ValueFrom: &secretsv1.ValueFrom{Template: _s(`{{ include "host" . }}`)}},
+		)
+		_, err := validator.ValidateCreate(context.Background(), cs)
+		Expect(err).ToNot(HaveOccurred())
+
+		cs.Spec.TemplateFrom = &secretsv1.TemplateFrom{Template: _s(`data: {{ dict "DB_USER" "app" | toJson }}`)}
+		cs.Spec.SecretMetadata.Annotations = map[string]string{"updated-at": `{{ (index .Secrets "random/aws/secret003").UpdatedAt }}`}
+		cs.Spec.SecretMetadata.TemplateMetadata = true
+		cs.Spec.SecretMetadata.PropagateTags = &secretsv1.PropagateTags{Tags: []string{"owner"}, Prefix: "aws.contentful.com"}
+		_, err = validator.ValidateCreate(context.Background(), cs)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Should reject invalid SyncedSecrets", func() {
+		for _, cs := range []*secretsv1.SyncedSecret{
+			syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), Value: _s("a")}, &secretsv1.SecretField{Name: _s("DB_NAME"), Value: _s("b")}),
+			syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME")}),
+			syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), Value: _s("a"), ValueFrom: &secretsv1.ValueFrom{Template: _s("b")}}),
+			syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), ValueFrom: &secretsv1.ValueFrom{}}),
+			syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), ValueFrom: &secretsv1.ValueFrom{Template: _s("{{ .Secrets")}}),
+			syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), ValueFrom: &secretsv1.ValueFrom{Template: _s(`{{ unknownFunc "a" }}`)}}),
+			syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), ValueFrom: &secretsv1.ValueFrom{TemplateRef: &secretsv1.TemplateRef{}}}),
+			syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), ValueFrom: &secretsv1.ValueFrom{Template: _s("a"), TemplateRef: &secretsv1.TemplateRef{Name: "dsn"}}}),
+		} {
+			_, err := validator.ValidateCreate(context.Background(), cs)
+			Expect(err).To(HaveOccurred())
+		}
+
+		cs := syncedSecret()
+		cs.Spec.TemplateFrom = &secretsv1.TemplateFrom{Template: _s("{{ .Secrets"), TemplateRef: &secretsv1.TemplateRef{Name: "data"}}
+		_, err := validator.ValidateCreate(context.Background(), cs)
+		Expect(err).To(MatchError(ContainSubstring("exactly one of template and templateRef must be set")))
+
+		cs = syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), Value: _s("a")})
+		cs.Spec.SecretMetadata.Labels = map[string]string{"team": "{{ .Secrets"}
+		_, err = validator.ValidateCreate(context.Background(), cs)
+		Expect(err).ToNot(HaveOccurred())
+		cs.Spec.SecretMetadata.TemplateMetadata = true
+		_, err = validator.ValidateCreate(context.Background(), cs)
+		Expect(err).To(MatchError(ContainSubstring("spec.secretMetadata.labels[team]")))
+
+		cs = syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), Value: _s("a")})
+		cs.Spec.SecretMetadata.PropagateTags = &secretsv1.PropagateTags{Tags: []string{"owner"}, Prefix: "Not A Prefix"}
+		_, err = validator.ValidateCreate(context.Background(), cs)
+		Expect(err).To(MatchError(ContainSubstring("spec.secretMetadata.propagateTags.prefix")))
+
+		cs = syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), Value: _s("a")})
+		cs.Spec.IAMRole = nil
+		_, err = validator.ValidateCreate(context.Background(), cs)
+		Expect(err).To(MatchError(ContainSubstring("one of IAMRole and AWSAccountID must be set")))
+	})
+
+	It("Should reject roles not allowed in the namespace of the Secret", func() {
+		cs := syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME"), Value: _s("a")})
+		cs.Spec.IAMRole = _s("other")
+		_, err := validator.ValidateCreate(context.Background(), cs)
+		Expect(err).To(MatchError(ContainSubstring("role other not allowed in namespace " + TEST_NAMESPACE)))
+
+		cs.Spec.IAMRole = _s("broken")
+		warnings, err := validator.ValidateCreate(context.Background(), cs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(warnings).To(HaveLen(1))
+	})
+
+	It("Should not validate SyncedSecrets being deleted", func() {
+		cs := syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME")})
+		now := metav1.Now()
+		cs.DeletionTimestamp = &now
+		_, err := validator.ValidateUpdate(context.Background(), cs, cs)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("Should only validate updates changing the spec", func() {
+		oldCs := syncedSecret(&secretsv1.SecretField{Name: _s("DB_NAME")})
+		cs := oldCs.DeepCopy()
+		cs.Finalizers = []string{Finalizer}
+		_, err := validator.ValidateUpdate(context.Background(), oldCs, cs)
+		Expect(err).ToNot(HaveOccurred())
+
+		cs.Spec.Data[0].Name = _s("DB_USER")
+		_, err = validator.ValidateUpdate(context.Background(), oldCs, cs)
+		Expect(err).To(HaveOccurred())
+	})
+})
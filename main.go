@@ -17,12 +17,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/contentful-labs/kube-secret-syncer/pkg/k8snamespace"
+	"github.com/contentful-labs/kube-secret-syncer/pkg/k8ssecret"
 	"github.com/contentful-labs/kube-secret-syncer/pkg/namespacevalidator"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -33,9 +35,11 @@ import (
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	secretsv2 "github.com/contentful-labs/kube-secret-syncer/api/v2"
 	"github.com/contentful-labs/kube-secret-syncer/controllers"
 	"github.com/contentful-labs/kube-secret-syncer/pkg/iam"
 	"github.com/contentful-labs/kube-secret-syncer/pkg/rolevalidator"
+	"github.com/contentful-labs/kube-secret-syncer/pkg/targetnamespacevalidator"
 	uzap "go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -58,6 +62,7 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = secretsv1.AddToScheme(scheme)
+	_ = secretsv2.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -85,6 +90,19 @@ func getDurationFromEnv(envVar string, defaultDuration time.Duration) (time.Dura
 	return defaultDuration, nil
 }
 
+func getIntFromEnv(envVar string, defaultValue int) (int, error) {
+	value, ok := os.LookupEnv(envVar)
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+
+	valueInt, err := strconv.Atoi(value)
+	if err != nil || valueInt < 0 {
+		return 0, fmt.Errorf("%s invalid: %s", envVar, value)
+	}
+	return valueInt, nil
+}
+
 func (s SMSVCFactory) getSMSVC(iamRole string) (secretsmanageriface.SecretsManagerAPI, error) {
 	var smsvc secretsmanageriface.SecretsManagerAPI
 	var err error
@@ -134,6 +152,11 @@ func realMain() int {
 		annotationName = "iam.amazonaws.com/allowed-roles"
 	}
 
+	sourceNamespacesAnnotationName := os.Getenv("NS_SOURCE_NAMESPACES_ANNOTATION")
+	if sourceNamespacesAnnotationName == "" {
+		sourceNamespacesAnnotationName = "secrets.contentful.com/allowed-source-namespaces"
+	}
+
 	syncPeriod, err := getDurationFromEnv("SYNC_INTERVAL_SEC", 120*time.Second)
 	if err != nil {
 		setupLog.Error(err, "failed parsing SYNC_INTERVAL_SEC: should be an integer")
@@ -146,6 +169,39 @@ func realMain() int {
 		return 1
 	}
 
+	minRefreshInterval, err := getDurationFromEnv("MIN_REFRESH_INTERVAL_SEC", 30*time.Second)
+	if err != nil {
+		setupLog.Error(err, "failed parsing MIN_REFRESH_INTERVAL_SEC: should be an integer")
+		return 1
+	}
+
+	templateTimeout, err := getDurationFromEnv("TEMPLATE_TIMEOUT_SEC", 10*time.Second)
+	if err != nil {
+		setupLog.Error(err, "failed parsing TEMPLATE_TIMEOUT_SEC: should be an integer")
+		return 1
+	}
+
+	templateMaxSecretReads, err := getIntFromEnv("TEMPLATE_MAX_SECRET_READS", 500)
+	if err != nil {
+		setupLog.Error(err, "failed parsing TEMPLATE_MAX_SECRET_READS: should be a positive integer")
+		return 1
+	}
+
+	// Kubernetes Secrets are limited to 1MiB
+	templateMaxOutputBytes, err := getIntFromEnv("TEMPLATE_MAX_OUTPUT_BYTES", 1024*1024)
+	if err != nil {
+		setupLog.Error(err, "failed parsing TEMPLATE_MAX_OUTPUT_BYTES: should be a positive integer")
+		return 1
+	}
+
+	clusterVariables := map[string]string{}
+	if value := os.Getenv("CLUSTER_VARIABLES"); value != "" {
+		if err := json.Unmarshal([]byte(value), &clusterVariables); err != nil {
+			setupLog.Error(err, "failed parsing CLUSTER_VARIABLES: should be a JSON object of strings")
+			return 1
+		}
+	}
+
 	logCfg := zapcore.EncoderConfig{
 		TimeKey:        "timestamp",
 		LevelKey:       "level",
@@ -193,16 +249,26 @@ func realMain() int {
 
 	roleValidator := rolevalidator.NewRoleValidator(arnClient, nsCache, annotationName)
 	namespaceValidator := namespacevalidator.NewNamespaceValidator(nsCache)
+	targetNamespaceValidator := targetnamespacevalidator.NewTargetNamespaceValidator(nsCache, sourceNamespacesAnnotationName)
 
 	r := &controllers.SyncedSecretReconciler{
-		Client:             mgr.GetClient(),
-		Log:                logger.WithName("controllers").WithName("SyncedSecret"),
-		Sess:               session.New(Retry5Cfg),
-		GetSMClient:        smsvcfactory.getSMSVC,
-		DefaultSearchRole:  defaultSearchRole,
-		RoleValidator:      roleValidator,
-		NamespaceValidator: namespaceValidator,
-		PollInterval:       pollInterval,
+		Client:                   mgr.GetClient(),
+		Log:                      logger.WithName("controllers").WithName("SyncedSecret"),
+		Sess:                     session.New(Retry5Cfg),
+		GetSMClient:              smsvcfactory.getSMSVC,
+		DefaultSearchRole:        defaultSearchRole,
+		RoleValidator:            roleValidator,
+		NamespaceValidator:       namespaceValidator,
+		TargetNamespaceValidator: targetNamespaceValidator,
+		PollInterval:             pollInterval,
+		MinRefreshInterval:       minRefreshInterval,
+		Namespaces:               nsCache,
+		ClusterVariables:         clusterVariables,
+		TemplateLimits: k8ssecret.TemplateLimits{
+			Timeout:        templateTimeout,
+			MaxSecretReads: templateMaxSecretReads,
+			MaxOutputSize:  templateMaxOutputBytes,
+		},
 	}
 
 	if err = r.SetupWithManager(mgr); err != nil {
@@ -211,6 +277,28 @@ func realMain() int {
 	}
 	defer r.Quit()
 
+	if err = (&controllers.ClusterSyncedSecretReconciler{
+		Client:        mgr.GetClient(),
+		Log:           logger.WithName("controllers").WithName("ClusterSyncedSecret"),
+		SyncedSecrets: r,
+		Namespaces:    nsCache,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterSyncedSecret")
+		return 1
+	}
+
+	// the webhooks also serve the conversion between the versions of the SyncedSecret API, which the API server
+	// relies on: the manifests in config/default enable them, along with the cert-manager certificate they need
+	if os.Getenv("ENABLE_WEBHOOKS") == "true" {
+		if err = (&controllers.SyncedSecretValidator{
+			RoleValidator: roleValidator,
+			Log:           logger.WithName("webhooks").WithName("SyncedSecret"),
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SyncedSecret")
+			return 1
+		}
+	}
+
 	// +kubebuilder:scaffold:builder
 	setupLog.Info("starting manager")
 	if err = mgr.Start(ctrl.SetupSignalHandler()); err != nil {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
+	"sync"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -22,6 +24,9 @@ type NamespaceGetter interface {
 type NamespaceCache struct {
 	indexer  cache.Indexer
 	informer cache.Controller
+
+	handlersLock sync.RWMutex
+	handlers     []func(*v1.Namespace)
 }
 
 func NewWatcher(ctx context.Context) (*NamespaceCache, error) {
@@ -45,25 +50,21 @@ func NewWatcher(ctx context.Context) (*NamespaceCache, error) {
 	}
 	source := cache.NewListWatchFromClient(client.CoreV1().RESTClient(), "namespaces", "", fields.Everything())
 
+	c := &NamespaceCache{}
 	eventHandler := &cache.FilteringResourceEventHandler{
 		FilterFunc: func(obj interface{}) bool {
-			_, isNamespace := obj.(*v1.Namespace)
+			_, isNamespace := namespaceFrom(obj)
 			if !isNamespace {
 				return false
 			}
 			// TODO change filter to relevant ns annotation
 			return true
 		},
-		Handler: &namespaceLogger{},
+		Handler: &namespaceEventHandler{cache: c},
 	}
 
 	// OnUpdate is called every resyncPeriod
-	indexer, informer := cache.NewIndexerInformer(source, &v1.Namespace{}, time.Minute, eventHandler, cache.Indexers{})
-
-	c := &NamespaceCache{
-		indexer:  indexer,
-		informer: informer,
-	}
+	c.indexer, c.informer = cache.NewIndexerInformer(source, &v1.Namespace{}, time.Minute, eventHandler, cache.Indexers{})
 
 	go c.informer.Run(ctx.Done())
 
@@ -87,14 +88,62 @@ func (c *NamespaceCache) Get(name string) (*v1.Namespace, error) {
 	return obj.(*v1.Namespace), nil
 }
 
-type namespaceLogger struct {
+// List returns all Namespaces in the cache
+func (c *NamespaceCache) List() ([]*v1.Namespace, error) {
+	namespaces := []*v1.Namespace{}
+	for _, obj := range c.indexer.List() {
+		namespaces = append(namespaces, obj.(*v1.Namespace))
+	}
+	return namespaces, nil
+}
+
+// OnChange registers a function that will be called whenever a Namespace is created, deleted, or
+// its labels or annotations change
+func (c *NamespaceCache) OnChange(handler func(*v1.Namespace)) {
+	c.handlersLock.Lock()
+	defer c.handlersLock.Unlock()
+	c.handlers = append(c.handlers, handler)
+}
+
+func (c *NamespaceCache) notify(ns *v1.Namespace) {
+	c.handlersLock.RLock()
+	defer c.handlersLock.RUnlock()
+	for _, handler := range c.handlers {
+		handler(ns)
+	}
+}
+
+type namespaceEventHandler struct {
+	cache *NamespaceCache
+}
+
+func (o *namespaceEventHandler) OnAdd(obj interface{}, isInInitialList bool) {
+	if isInInitialList {
+		return
+	}
+	o.cache.notify(obj.(*v1.Namespace))
 }
 
-func (o *namespaceLogger) OnAdd(obj interface{}, isInInitialList bool) {
+func (o *namespaceEventHandler) OnDelete(obj interface{}) {
+	ns, _ := namespaceFrom(obj)
+	o.cache.notify(ns)
 }
 
-func (o *namespaceLogger) OnDelete(obj interface{}) {
+// OnUpdate is also called on every resync, we only notify when labels or annotations changed
+func (o *namespaceEventHandler) OnUpdate(old, new interface{}) {
+	oldNs, newNs := old.(*v1.Namespace), new.(*v1.Namespace)
+	if reflect.DeepEqual(oldNs.Labels, newNs.Labels) && reflect.DeepEqual(oldNs.Annotations, newNs.Annotations) {
+		return
+	}
+	o.cache.notify(newNs)
 }
 
-func (o *namespaceLogger) OnUpdate(old, new interface{}) {
+// namespaceFrom returns the Namespace of an informer event, unwrapping the tombstone of a Namespace whose deletion
+// was missed while the watch was disconnected
+func namespaceFrom(obj interface{}) (*v1.Namespace, bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	ns, ok := obj.(*v1.Namespace)
+	return ns, ok
 }
@@ -0,0 +1,80 @@
+package k8ssecret
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DecodingError is returned when a value can not be decoded with its decoding strategy
+type DecodingError struct {
+	err error
+}
+
+func (e *DecodingError) Error() string {
+	return e.err.Error()
+}
+
+func (e *DecodingError) Unwrap() error {
+	return e.err
+}
+
+// decodeValue decodes a value read from Secrets Manager according to strategy
+func decodeValue(strategy secretsv1.DecodingStrategy, value []byte) ([]byte, error) {
+	var decoded []byte
+	var err error
+
+	switch strategy {
+	case "", secretsv1.DecodingStrategyNone:
+		return value, nil
+	case secretsv1.DecodingStrategyBase64:
+		decoded, err = decodeBase64(base64.StdEncoding, base64.RawStdEncoding, value)
+	case secretsv1.DecodingStrategyBase64URL:
+		decoded, err = decodeBase64(base64.URLEncoding, base64.RawURLEncoding, value)
+	case secretsv1.DecodingStrategyHex:
+		decoded, err = hex.DecodeString(strings.TrimSpace(string(value)))
+	case secretsv1.DecodingStrategyGzip:
+		decoded, err = decodeGzip(value)
+	default:
+		err = fmt.Errorf("unknown decoding strategy")
+	}
+
+	if err != nil {
+		return nil, &DecodingError{fmt.Errorf("failed decoding value with strategy %s: %w", strategy, err)}
+	}
+	return decoded, nil
+}
+
+// decodeBase64 decodes padded or unpadded base64, ignoring line breaks such as those in PEM-style wrapped values
+func decodeBase64(padded, unpadded *base64.Encoding, value []byte) ([]byte, error) {
+	encoded := strings.Join(strings.Fields(string(value)), "")
+	if strings.HasSuffix(encoded, "=") || len(encoded)%4 == 0 {
+		return padded.DecodeString(encoded)
+	}
+	return unpadded.DecodeString(encoded)
+}
+
+// decodeGzip decompresses value, refusing to produce more than a Secret can hold
+func decodeGzip(value []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(value))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	decoded, err := io.ReadAll(io.LimitReader(reader, corev1.MaxSecretSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) > corev1.MaxSecretSize {
+		return nil, fmt.Errorf("decompressed value is larger than %d bytes", corev1.MaxSecretSize)
+	}
+	return decoded, nil
+}
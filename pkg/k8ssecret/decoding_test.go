@@ -0,0 +1,61 @@
+package k8ssecret
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func gzipped(t *testing.T, value []byte) []byte {
+	buf := new(bytes.Buffer)
+	writer := gzip.NewWriter(buf)
+	if _, err := writer.Write(value); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeValue(t *testing.T) {
+	testCases := []struct {
+		name     string
+		strategy secretsv1.DecodingStrategy
+		value    []byte
+		want     []byte
+		wantErr  bool
+	}{
+		{name: "no strategy", strategy: "", value: []byte("aGVsbG8="), want: []byte("aGVsbG8=")},
+		{name: "None", strategy: secretsv1.DecodingStrategyNone, value: []byte("aGVsbG8="), want: []byte("aGVsbG8=")},
+		{name: "Base64", strategy: secretsv1.DecodingStrategyBase64, value: []byte("aGVsbG8="), want: []byte("hello")},
+		{name: "Base64 without padding", strategy: secretsv1.DecodingStrategyBase64, value: []byte("aGVsbG8"), want: []byte("hello")},
+		{name: "Base64 with line breaks", strategy: secretsv1.DecodingStrategyBase64, value: []byte("aGVs\nbG8=\n"), want: []byte("hello")},
+		{name: "invalid Base64", strategy: secretsv1.DecodingStrategyBase64, value: []byte("not base64!"), wantErr: true},
+		{name: "Base64URL", strategy: secretsv1.DecodingStrategyBase64URL, value: []byte("_-8"), want: []byte{0xff, 0xef}},
+		{name: "Hex", strategy: secretsv1.DecodingStrategyHex, value: []byte("68656c6c6f\n"), want: []byte("hello")},
+		{name: "invalid Hex", strategy: secretsv1.DecodingStrategyHex, value: []byte("xyz"), wantErr: true},
+		{name: "Gzip", strategy: secretsv1.DecodingStrategyGzip, value: gzipped(t, []byte("hello")), want: []byte("hello")},
+		{name: "invalid Gzip", strategy: secretsv1.DecodingStrategyGzip, value: []byte("hello"), wantErr: true},
+		{name: "Gzip larger than a Secret", strategy: secretsv1.DecodingStrategyGzip, value: gzipped(t, make([]byte, corev1.MaxSecretSize+1)), wantErr: true},
+		{name: "unknown strategy", strategy: "rot13", value: []byte("hello"), wantErr: true},
+	}
+
+	for _, test := range testCases {
+		got, err := decodeValue(test.strategy, test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		var decodingErr *DecodingError
+		if err != nil && !errors.As(err, &decodingErr) {
+			t.Errorf("%s: wanted a DecodingError, got %v", test.name, err)
+		}
+		if !bytes.Equal(got, test.want) {
+			t.Errorf("%s: wanted %q, got %q", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package k8ssecret
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeyNotFoundError is returned when a key referenced by a secretKeyRef does not exist in the secret
+type KeyNotFoundError struct {
+	Key string
+	err error
+}
+
+func (e *KeyNotFoundError) Error() string {
+	return e.err.Error()
+}
+
+// lookupKey returns the value for key in a secret decoded from JSON. If the secret has no top-level key named key,
+// key is read as a path to a nested value, e.g. db.primary.password, hosts[0].name or $.db["key.with.dots"]. A key
+// that is neither a top-level key nor a valid path is not found, like any other missing key.
+func lookupKey(values map[string]interface{}, key string) (interface{}, error) {
+	if value, ok := values[key]; ok {
+		return value, nil
+	}
+
+	path, err := parseKeyPath(key)
+	if err != nil {
+		return nil, &KeyNotFoundError{key, fmt.Errorf("key %s not found, and it is not a valid path: %s", key, err)}
+	}
+
+	var current interface{} = values
+	for i, segment := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[segment]
+			if !ok {
+				return nil, &KeyNotFoundError{key, fmt.Errorf("key %s not found", formatKeyPath(path[:i+1]))}
+			}
+			current = value
+
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return nil, &KeyNotFoundError{key, fmt.Errorf("%s is a list, %s is not a valid index", formatKeyPath(path[:i]), segment)}
+			}
+			if index < 0 || index >= len(node) {
+				return nil, &KeyNotFoundError{key, fmt.Errorf("index %d out of range for %s, which has %d elements", index, formatKeyPath(path[:i]), len(node))}
+			}
+			current = node[index]
+
+		default:
+			return nil, &KeyNotFoundError{key, fmt.Errorf("%s is not an object or a list", formatKeyPath(path[:i]))}
+		}
+	}
+
+	return current, nil
+}
+
+// parseKeyPath splits a path such as $.db.hosts[0]["key.with.dots"] into its segments
+func parseKeyPath(key string) ([]string, error) {
+	path := key
+	if strings.HasPrefix(path, "$.") || strings.HasPrefix(path, "$[") {
+		path = path[1:]
+	} else if path != "" && path[0] != '[' {
+		path = "." + path
+	}
+
+	segments := []string{}
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '.':
+			end := i + 1
+			for end < len(path) && path[end] != '.' && path[end] != '[' {
+				end++
+			}
+			if end == i+1 {
+				return nil, fmt.Errorf("invalid key path %s: empty key", key)
+			}
+			segments = append(segments, path[i+1:end])
+			i = end
+
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid key path %s: missing ]", key)
+			}
+			segment := path[i+1 : i+end]
+			if unquoted, err := strconv.Unquote(segment); err == nil {
+				segment = unquoted
+			} else if len(segment) >= 2 && segment[0] == '\'' && segment[len(segment)-1] == '\'' {
+				segment = segment[1 : len(segment)-1]
+			} else if _, err := strconv.Atoi(segment); err != nil {
+				return nil, fmt.Errorf("invalid key path %s: %s is not an index or a quoted key", key, segment)
+			}
+			segments = append(segments, segment)
+			i += end + 1
+
+		default:
+			return nil, fmt.Errorf("invalid key path %s: unexpected %q", key, path[i])
+		}
+	}
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid key path %s: empty key", key)
+	}
+
+	return segments, nil
+}
+
+func formatKeyPath(segments []string) string {
+	if len(segments) == 0 {
+		return "secret"
+	}
+	return strings.Join(segments, ".")
+}
@@ -0,0 +1,62 @@
+package k8ssecret
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLookupKey(t *testing.T) {
+	secret := `{
+		"password": "toplevel",
+		"db.password": "dotted",
+		"db": {
+			"primary": {"password": "primary-pass"},
+			"replicas": [{"host": "replica-0"}, {"host": "replica-1"}],
+			"key.with.dots": "quoted"
+		}
+	}`
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name         string
+		key          string
+		want         interface{}
+		wantErr      bool
+		wantNotFound bool
+	}{
+		{name: "top-level key", key: "password", want: "toplevel"},
+		{name: "top-level key containing dots takes precedence", key: "db.password", want: "dotted"},
+		{name: "dotted path", key: "db.primary.password", want: "primary-pass"},
+		{name: "JSONPath", key: "$.db.primary.password", want: "primary-pass"},
+		{name: "array index", key: "db.replicas[1].host", want: "replica-1"},
+		{name: "array index as dotted path", key: "db.replicas.0.host", want: "replica-0"},
+		{name: "quoted key", key: `db["key.with.dots"]`, want: "quoted"},
+		{name: "single quoted key", key: `$['db']['key.with.dots']`, want: "quoted"},
+		{name: "missing key", key: "missing", wantErr: true, wantNotFound: true},
+		{name: "missing nested key", key: "db.primary.user", wantErr: true, wantNotFound: true},
+		{name: "index out of range", key: "db.replicas[2].host", wantErr: true, wantNotFound: true},
+		{name: "key in a list", key: "db.replicas.host", wantErr: true, wantNotFound: true},
+		{name: "path through a string", key: "password.length", wantErr: true, wantNotFound: true},
+		{name: "empty key", key: "db..password", wantErr: true, wantNotFound: true},
+		{name: "unterminated index", key: "db.replicas[0", wantErr: true, wantNotFound: true},
+	}
+
+	for _, test := range testCases {
+		got, err := lookupKey(values, test.key)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		var notFound *KeyNotFoundError
+		if errors.As(err, &notFound) != test.wantNotFound {
+			t.Errorf("%s: wanted KeyNotFoundError %v, got %v", test.name, test.wantNotFound, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: wanted %v, got %v", test.name, test.want, got)
+		}
+	}
+}
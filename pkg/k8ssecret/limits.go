@@ -0,0 +1,168 @@
+package k8ssecret
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"text/template"
+	"time"
+
+	"github.com/contentful-labs/kube-secret-syncer/pkg/secretsmanager"
+)
+
+// TemplateLimits bounds the rendering of the templates of a SyncedSecret, so that a badly written template can not
+// make an unbounded number of calls to Secrets Manager or block the reconciler. Zero values disable a limit.
+type TemplateLimits struct {
+	// Timeout for rendering all the templates of a SyncedSecret
+	Timeout time.Duration
+	// MaxSecretReads is the number of getSecretValue and getSecretValueMap calls allowed while rendering them
+	MaxSecretReads int
+	// MaxOutputSize is the number of bytes all the templates of a SyncedSecret can render together
+	MaxOutputSize int
+}
+
+// TemplateLimitError is returned when rendering a template exceeds one of its limits
+type TemplateLimitError struct {
+	msg string
+}
+
+func (e *TemplateLimitError) Error() string {
+	return e.msg
+}
+
+// templateBudget tracks the limits of the templates of a SyncedSecret while they are rendered
+type templateBudget struct {
+	limits   TemplateLimits
+	deadline time.Time
+	// ctx is cancelled when the timeout expires or the SyncedSecret is rendered, cancelling the secret reads of
+	// templates still running
+	ctx    context.Context
+	cancel context.CancelFunc
+	reads  int
+	// output is the number of bytes rendered so far by the templates of the SyncedSecret
+	output int
+}
+
+// newTemplateBudget returns the budget of the templates of a SyncedSecret. It must be cancelled once the SyncedSecret
+// is rendered.
+func newTemplateBudget(ctx context.Context, limits TemplateLimits) *templateBudget {
+	budget := &templateBudget{limits: limits}
+	if limits.Timeout > 0 {
+		budget.deadline = time.Now().Add(limits.Timeout)
+		budget.ctx, budget.cancel = context.WithDeadline(ctx, budget.deadline)
+	} else {
+		budget.ctx, budget.cancel = context.WithCancel(ctx)
+	}
+	return budget
+}
+
+// checkDeadline returns an error once the timeout expired or the budget was cancelled, so that templates stop at
+// their next function call or write
+func (b *templateBudget) checkDeadline() error {
+	if !b.deadline.IsZero() && time.Now().After(b.deadline) {
+		return b.timeoutError()
+	}
+	return b.ctx.Err()
+}
+
+func (b *templateBudget) timeoutError() error {
+	return &TemplateLimitError{fmt.Sprintf("rendering templates took longer than %s", b.limits.Timeout)}
+}
+
+// secretValueGetter counts the secrets read by templates with secretValueGetter against the budget. Reads are made
+// with the context of the budget, and their result is discarded if it was cancelled meanwhile.
+func (b *templateBudget) secretValueGetter(secretValueGetter func(context.Context, string, string, secretsmanager.SecretVersion) (string, error)) func(string, string, secretsmanager.SecretVersion) (string, error) {
+	return func(secretID string, iamrole string, version secretsmanager.SecretVersion) (string, error) {
+		if err := b.checkDeadline(); err != nil {
+			return "", err
+		}
+		if b.limits.MaxSecretReads > 0 && b.reads >= b.limits.MaxSecretReads {
+			return "", &TemplateLimitError{fmt.Sprintf("templates read more than %d secrets", b.limits.MaxSecretReads)}
+		}
+		b.reads++
+		value, err := secretValueGetter(b.ctx, secretID, iamrole, version)
+		if cancelled := b.checkDeadline(); cancelled != nil {
+			return "", cancelled
+		}
+		return value, err
+	}
+}
+
+// withDeadline wraps the template function f so that it fails once the timeout expired. Functions without an error
+// result panic instead, which text/template turns into an error of the template calling them.
+func (b *templateBudget) withDeadline(f interface{}) interface{} {
+	fn := reflect.ValueOf(f)
+	fnType := fn.Type()
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		if err := b.checkDeadline(); err != nil {
+			if fnType.NumOut() != 2 {
+				panic(err)
+			}
+			return []reflect.Value{reflect.Zero(fnType.Out(0)), reflect.ValueOf(&err).Elem()}
+		}
+		if fnType.IsVariadic() {
+			return fn.CallSlice(args)
+		}
+		return fn.Call(args)
+	}).Interface()
+}
+
+// execute renders the template called name, giving up when the timeout expires
+func (b *templateBudget) execute(tpl *template.Template, name string, data interface{}) ([]byte, error) {
+	buf := &limitedBuffer{budget: b, counted: true}
+	if b.deadline.IsZero() {
+		err := tpl.ExecuteTemplate(buf, name, data)
+		return buf.Bytes(), err
+	}
+
+	// text/template can not be interrupted: a template still running after the timeout stops with an error at its
+	// next function call or write, its secret reads are cancelled, and its output is discarded
+	done := make(chan error, 1)
+	go func() {
+		done <- tpl.ExecuteTemplate(buf, name, data)
+	}()
+	timer := time.NewTimer(time.Until(b.deadline))
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return buf.Bytes(), err
+	case <-timer.C:
+		return nil, b.timeoutError()
+	}
+}
+
+// newBuffer returns a buffer for an included template. What it holds is counted against the maximum output size
+// once written to the template including it.
+func (b *templateBudget) newBuffer() *limitedBuffer {
+	return &limitedBuffer{budget: b}
+}
+
+func (b *templateBudget) outputError() error {
+	return &TemplateLimitError{fmt.Sprintf("templates rendered more than %d bytes", b.limits.MaxOutputSize)}
+}
+
+// limitedBuffer is a bytes.Buffer failing writes once the timeout expired or past the maximum output size
+type limitedBuffer struct {
+	bytes.Buffer
+	budget *templateBudget
+	// counted buffers hold the output of a template, and count it against the output of the SyncedSecret
+	counted bool
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	if err := l.budget.checkDeadline(); err != nil {
+		return 0, err
+	}
+	if max := l.budget.limits.MaxOutputSize; max > 0 {
+		if l.counted {
+			if l.budget.output+len(p) > max {
+				return 0, l.budget.outputError()
+			}
+			l.budget.output += len(p)
+		} else if l.budget.output+l.Len()+len(p) > max {
+			return 0, l.budget.outputError()
+		}
+	}
+	return l.Buffer.Write(p)
+}
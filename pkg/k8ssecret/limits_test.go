@@ -0,0 +1,168 @@
+package k8ssecret
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	"github.com/contentful-labs/kube-secret-syncer/pkg/secretsmanager"
+)
+
+func mockgetSlowSecretValue(ctx context.Context, secretID string, role string, version secretsmanager.SecretVersion) (string, error) {
+	time.Sleep(50 * time.Millisecond)
+	return mockgetSecretValue(ctx, secretID, role, version)
+}
+
+func TestTemplateLimits(t *testing.T) {
+	testCases := []struct {
+		name              string
+		template          string
+		otherTemplate     string
+		templateFrom      bool
+		limits            TemplateLimits
+		secretValueGetter func(context.Context, string, string, secretsmanager.SecretVersion) (string, error)
+		wantLimitError    bool
+	}{
+		{
+			name:              "it should render templates within their limits",
+			template:          `{{ range sortedNames .Secrets }}{{ (getSecretValueMap .).key1 }}{{ end }}`,
+			limits:            TemplateLimits{Timeout: time.Second, MaxSecretReads: 3, MaxOutputSize: 18},
+			secretValueGetter: mockgetSecretValue,
+		},
+		{
+			name:              "it should fail when templates read more secrets than allowed",
+			template:          `{{ range sortedNames .Secrets }}{{ getSecretValue . }}{{ end }}`,
+			limits:            TemplateLimits{MaxSecretReads: 2},
+			secretValueGetter: mockgetSecretValue,
+			wantLimitError:    true,
+		},
+		{
+			name:              "it should fail when getSecretValueMap reads more secrets than allowed",
+			template:          `{{ range sortedNames .Secrets }}{{ (getSecretValueMap .).key1 }}{{ end }}`,
+			limits:            TemplateLimits{MaxSecretReads: 2},
+			secretValueGetter: mockgetSecretValue,
+			wantLimitError:    true,
+		},
+		{
+			name:              "it should fail when a template renders more than the maximum output size",
+			template:          `{{ range sortedNames .Secrets }}{{ (getSecretValueMap .).key1 }}{{ end }}`,
+			limits:            TemplateLimits{MaxOutputSize: 17},
+			secretValueGetter: mockgetSecretValue,
+			wantLimitError:    true,
+		},
+		{
+			name:              "it should fail when an included template renders more than the maximum output size",
+			template:          `{{ include "values" . | trunc 1 }}`,
+			limits:            TemplateLimits{MaxOutputSize: 17},
+			secretValueGetter: mockgetSecretValue,
+			wantLimitError:    true,
+		},
+		{
+			name:              "it should fail when the templates render more than the maximum output size together",
+			template:          `{{ include "values" . }}`,
+			otherTemplate:     `{{ include "values" . }}`,
+			limits:            TemplateLimits{MaxOutputSize: 30},
+			secretValueGetter: mockgetSecretValue,
+			wantLimitError:    true,
+		},
+		{
+			name:              "it should fail when rendering the templates takes longer than the timeout",
+			template:          `{{ range sortedNames .Secrets }}{{ getSecretValue . }}{{ end }}`,
+			limits:            TemplateLimits{Timeout: 75 * time.Millisecond},
+			secretValueGetter: mockgetSlowSecretValue,
+			wantLimitError:    true,
+		},
+		{
+			name:              "it should stop templates calling functions after the timeout",
+			template:          `{{ range until 1000000 }}{{ range until 1000 }}{{ end }}{{ end }}`,
+			limits:            TemplateLimits{Timeout: 50 * time.Millisecond},
+			secretValueGetter: mockgetSecretValue,
+			wantLimitError:    true,
+		},
+		{
+			name:              "it should apply the limits to templateFrom",
+			template:          `data: {{ range sortedNames .Secrets }}{{ getSecretValue . | quote }}{{ end }}`,
+			templateFrom:      true,
+			limits:            TemplateLimits{MaxSecretReads: 1},
+			secretValueGetter: mockgetSecretValue,
+			wantLimitError:    true,
+		},
+	}
+
+	for _, test := range testCases {
+		cs := secretsv1.SyncedSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret-name",
+				Namespace: "secret-namespace",
+			},
+			Spec: secretsv1.SyncedSecretSpec{
+				IAMRole: _s("iam_role"),
+			},
+		}
+		if test.templateFrom {
+			cs.Spec.TemplateFrom = &secretsv1.TemplateFrom{Template: _s(test.template)}
+		} else {
+			cs.Spec.Data = []*secretsv1.SecretField{
+				{Name: _s("foo"), ValueFrom: &secretsv1.ValueFrom{Template: _s(test.template)}},
+			}
+			if test.otherTemplate != "" {
+				cs.Spec.Data = append(cs.Spec.Data, &secretsv1.SecretField{Name: _s("bar"), ValueFrom: &secretsv1.ValueFrom{Template: _s(test.otherTemplate)}})
+			}
+		}
+		tplContext := TemplateContext{
+			SecretTemplates: map[string]string{
+				"values": `{{ range sortedNames .Secrets }}{{ (getSecretValueMap .).key1 }}{{ end }}`,
+			},
+			Limits: test.limits,
+		}
+		secrets := secretsmanager.Secrets{"cachedSecret1": {}, "cachedSecret2": {}, "cachedSecret3": {}}
+
+		_, err := GenerateK8SSecret(context.Background(), cs, secrets, tplContext, test.secretValueGetter, secretsmanager.FilterByTagKey, logr.Logger{})
+		var limitErr *TemplateLimitError
+		if test.wantLimitError != errors.As(err, &limitErr) {
+			t.Errorf("%s: expected a TemplateLimitError: %t, got error: %v", test.name, test.wantLimitError, err)
+		}
+		if !test.wantLimitError && err != nil {
+			t.Errorf("%s: unexpected error: %s", test.name, err)
+		}
+	}
+}
+
+func TestTemplateTimeoutCancelsSecretReads(t *testing.T) {
+	readErrs := make(chan error, 1)
+	blockingSecretValueGetter := func(ctx context.Context, secretID string, role string, version secretsmanager.SecretVersion) (string, error) {
+		select {
+		case <-ctx.Done():
+			readErrs <- ctx.Err()
+			return "", ctx.Err()
+		case <-time.After(time.Second):
+			readErrs <- nil
+			return mockgetSecretValue(ctx, secretID, role, version)
+		}
+	}
+	cs := secretsv1.SyncedSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret-name", Namespace: "secret-namespace"},
+		Spec: secretsv1.SyncedSecretSpec{
+			IAMRole: _s("iam_role"),
+			Data: []*secretsv1.SecretField{
+				{Name: _s("foo"), ValueFrom: &secretsv1.ValueFrom{Template: _s(`{{ getSecretValue "cachedSecret1" }}`)}},
+			},
+		},
+	}
+	tplContext := TemplateContext{Limits: TemplateLimits{Timeout: 50 * time.Millisecond}}
+	secrets := secretsmanager.Secrets{"cachedSecret1": {}}
+
+	_, err := GenerateK8SSecret(context.Background(), cs, secrets, tplContext, blockingSecretValueGetter, secretsmanager.FilterByTagKey, logr.Logger{})
+	var limitErr *TemplateLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected a TemplateLimitError, got: %v", err)
+	}
+	if err := <-readErrs; err == nil {
+		t.Errorf("expected the secret read still running after the timeout to be cancelled")
+	}
+}
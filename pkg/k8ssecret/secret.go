@@ -1,14 +1,17 @@
 package k8ssecret
 
 import (
-	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"text/template"
 
-	"github.com/Masterminds/sprig"
 	"github.com/contentful-labs/kube-secret-syncer/pkg/secretsmanager"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
@@ -16,8 +19,59 @@ import (
 	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
+const (
+	// AnnotationSyncedSecret is set on generated Secrets to the namespace/name of the SyncedSecret managing them
+	AnnotationSyncedSecret = "secrets.contentful.com/synced-secret"
+	// AnnotationClusterSyncedSecret is set on generated Secrets to the name of the ClusterSyncedSecret managing them
+	AnnotationClusterSyncedSecret = "secrets.contentful.com/cluster-synced-secret"
+
+	// LabelManagedBy is set to ManagedBy on the Secrets managed by kube-secret-syncer
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+	ManagedBy      = "kube-secret-syncer"
+)
+
+// requiredKeysBySecretType lists the keys Kubernetes expects to be present for the well-known Secret types
+var requiredKeysBySecretType = map[corev1.SecretType][]string{
+	corev1.SecretTypeTLS:              {corev1.TLSCertKey, corev1.TLSPrivateKeyKey},
+	corev1.SecretTypeDockerConfigJson: {corev1.DockerConfigJsonKey},
+	corev1.SecretTypeDockercfg:        {corev1.DockerConfigKey},
+	corev1.SecretTypeSSHAuth:          {corev1.SSHAuthPrivateKey},
+}
+
+// TemplateError is returned when a templated field fails to parse or execute
+type TemplateError struct {
+	err error
+}
+
+func (e *TemplateError) Error() string {
+	return e.err.Error()
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.err
+}
+
+// SecretName returns the name and namespace of the Secret generated for a SyncedSecret. They default to
+// the name and namespace of the SyncedSecret, unless overridden in the secret metadata.
+func SecretName(cs secretsv1.SyncedSecret) types.NamespacedName {
+	name := types.NamespacedName{
+		Name:      cs.ObjectMeta.Name,
+		Namespace: cs.ObjectMeta.Namespace,
+	}
+	if cs.Spec.SecretMetadata.Name != "" {
+		name.Name = cs.Spec.SecretMetadata.Name
+	}
+	if cs.Spec.SecretMetadata.Namespace != "" {
+		name.Namespace = cs.Spec.SecretMetadata.Namespace
+	}
+
+	return name
+}
+
 func K8SSecretsEqual(secret1, secret2 corev1.Secret) bool {
 	if !reflect.DeepEqual(secret1.Data, secret2.Data) {
 		return false
@@ -31,155 +85,387 @@ func K8SSecretsEqual(secret1, secret2 corev1.Secret) bool {
 }
 
 func GenerateK8SSecret(
+	ctx context.Context,
 	cs secretsv1.SyncedSecret,
 	secrets secretsmanager.Secrets,
-	secretValueGetter func(string, string) (string, error),
+	tplContext TemplateContext,
+	secretValueGetter func(context.Context, string, string, secretsmanager.SecretVersion) (string, error),
 	secretFilterByTagKey func(secretsmanager.Secrets, string) secretsmanager.Secrets,
 	log logr.Logger,
 ) (*corev1.Secret, error) {
+	// record the secrets read, to propagate their tags
+	readSecrets := map[string]bool{}
+	if cs.Spec.SecretMetadata.PropagateTags != nil {
+		readSecretValue := secretValueGetter
+		secretValueGetter = func(ctx context.Context, secretID string, iamrole string, version secretsmanager.SecretVersion) (string, error) {
+			value, err := readSecretValue(ctx, secretID, iamrole, version)
+			// reads of templates that timed out are discarded
+			if ctx.Err() == nil {
+				readSecrets[secretID] = true
+			}
+			return value, err
+		}
+	}
+
+	// templates share a budget of secret reads and time
+	budget := newTemplateBudget(ctx, tplContext.Limits)
+	defer budget.cancel()
+	templateValueGetter := budget.secretValueGetter(secretValueGetter)
+	render := func(text *string, ref *secretsv1.TemplateRef) ([]byte, error) {
+		return renderTemplate(cs.Namespace+"/"+cs.Name, text, ref, newTemplateData(cs, secrets, tplContext), tplContext.SecretTemplates, templateFuncs(templateValueGetter, iamRole(cs), secretFilterByTagKey), budget)
+	}
+
 	annotations := map[string]string{}
-	if cs.Spec.SecretMetadata.Annotations != nil {
-		for key, val := range cs.Spec.SecretMetadata.Annotations {
-			annotations[key] = val
+	for key, val := range cs.Spec.SecretMetadata.Annotations {
+		rendered, err := renderMetadataValue(render, cs.Spec.SecretMetadata, val)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed rendering annotation %s", key)
 		}
+		annotations[key] = rendered
 	}
 
 	labels := map[string]string{}
-	if cs.Spec.SecretMetadata.Labels != nil {
-		for key, val := range cs.Spec.SecretMetadata.Labels {
-			labels[key] = val
+	for key, val := range cs.Spec.SecretMetadata.Labels {
+		rendered, err := renderMetadataValue(render, cs.Spec.SecretMetadata, val)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed rendering label %s", key)
+		}
+		if msgs := validation.IsValidLabelValue(rendered); len(msgs) > 0 {
+			return nil, &TemplateError{fmt.Errorf("label %s has an invalid value %q: %s", key, rendered, strings.Join(msgs, ", "))}
 		}
+		labels[key] = rendered
 	}
 
+	secretName := SecretName(cs)
 	secretMeta := metav1.ObjectMeta{
-		Name:      cs.ObjectMeta.Name,
-		Namespace: cs.ObjectMeta.Namespace,
-	}
-	if len(annotations) > 0 {
-		secretMeta.Annotations = annotations
-	}
-	if len(labels) > 0 {
-		secretMeta.Labels = labels
+		Name:      secretName.Name,
+		Namespace: secretName.Namespace,
 	}
 
 	// Now to the data...
 	data := make(map[string][]byte)
 	if cs.Spec.DataFrom != nil {
 		var secretRef *string // secretID of the secret in secret Manager
+		var version secretsmanager.SecretVersion
 		if cs.Spec.DataFrom.SecretRef != nil {
 			secretRef = cs.Spec.DataFrom.SecretRef.Name
+			version = secretRefVersion(cs.Spec.DataFrom.SecretRef)
 		}
 
 		if secretRef != nil {
-			var iamrole string
-			if cs.Spec.AWSAccountID != nil {
-				iamrole = fmt.Sprintf("arn:aws:iam::%s:role/secret-syncer", *cs.Spec.AWSAccountID)
-			} else {
-				iamrole = *cs.Spec.IAMRole
-			}
-			AWSSecretValue, err := secretValueGetter(*secretRef, iamrole)
+			AWSSecretValue, err := secretValueGetter(ctx, *secretRef, iamRole(cs), version)
 			if err != nil {
 				return nil, err
 			}
-			var AWSSecretValuesMap map[string]interface{}
-			err = json.Unmarshal([]byte(AWSSecretValue), &AWSSecretValuesMap)
+			AWSSecretValuesMap, err := decodeJSONSecret(AWSSecretValue)
 			if err != nil {
 				return nil, fmt.Errorf("secret %s is not a valid JSON", *secretRef)
 			}
+			if cs.Spec.DataFrom.Flatten {
+				AWSSecretValuesMap = flattenValues(AWSSecretValuesMap)
+			}
 			for secretKey, secretValue := range AWSSecretValuesMap {
-				data[secretKey] = []byte(fmt.Sprintf("%v", secretValue))
+				value, err := formatValue(secretValue)
+				if err != nil {
+					return nil, errors.WithMessagef(err, "failed formatting key %s of secret %s", secretKey, *secretRef)
+				}
+				decoded, err := decodeValue(cs.Spec.DataFrom.DecodingStrategy, []byte(value))
+				if err != nil {
+					return nil, errors.WithMessagef(err, "failed decoding key %s of secret %s", secretKey, *secretRef)
+				}
+				data[secretKey] = decoded
 			}
 		}
 	}
 
-	if cs.Spec.Data != nil {
-		var iamrole string
-		if cs.Spec.AWSAccountID != nil {
-			iamrole = fmt.Sprintf("arn:aws:iam::%s:role/secret-syncer", *cs.Spec.AWSAccountID)
-		} else {
-			iamrole = *cs.Spec.IAMRole
+	if cs.Spec.TemplateFrom != nil {
+		rendered, err := render(cs.Spec.TemplateFrom.Template, cs.Spec.TemplateFrom.TemplateRef)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed rendering templateFrom")
 		}
+		output, err := parseTemplateFromOutput(rendered)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range output.Data {
+			if msgs := validation.IsConfigMapKey(key); len(msgs) > 0 {
+				return nil, &TemplateError{fmt.Errorf("templateFrom rendered an invalid data key %q: %s", key, strings.Join(msgs, ", "))}
+			}
+			formatted, err := formatValue(value)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed formatting key %s of templateFrom", key)
+			}
+			decoded, err := decodeValue(cs.Spec.TemplateFrom.DecodingStrategy, []byte(formatted))
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed decoding key %s of templateFrom", key)
+			}
+			data[key] = decoded
+		}
+		// the secret metadata takes precedence over the rendered labels and annotations
+		for key, val := range output.Labels {
+			if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+				return nil, &TemplateError{fmt.Errorf("templateFrom rendered an invalid label key %q: %s", key, strings.Join(msgs, ", "))}
+			}
+			if msgs := validation.IsValidLabelValue(val); len(msgs) > 0 {
+				return nil, &TemplateError{fmt.Errorf("label %s has an invalid value %q: %s", key, val, strings.Join(msgs, ", "))}
+			}
+			if _, ok := labels[key]; !ok {
+				labels[key] = val
+			}
+		}
+		for key, val := range output.Annotations {
+			if msgs := validation.IsQualifiedName(strings.ToLower(key)); len(msgs) > 0 {
+				return nil, &TemplateError{fmt.Errorf("templateFrom rendered an invalid annotation key %q: %s", key, strings.Join(msgs, ", "))}
+			}
+			if _, ok := annotations[key]; !ok {
+				annotations[key] = val
+			}
+		}
+	}
+	if cs.Spec.Data != nil {
+		iamrole := iamRole(cs)
 		for _, field := range cs.Spec.Data {
 			if field.Value != nil {
 				data[*field.Name] = []byte(*field.Value)
 			}
 
 			if field.ValueFrom != nil {
+				// only the value read by this field is decoded, data may hold a value written by dataFrom or
+				// templateFrom when an optional key is missing
+				var value []byte
+				found := false
+
 				if field.ValueFrom.SecretRef != nil {
-					AWSSecretValue, err := secretValueGetter(*field.ValueFrom.SecretRef.Name, iamrole)
+					AWSSecretValue, err := secretValueGetter(ctx, *field.ValueFrom.SecretRef.Name, iamrole, secretRefVersion(field.ValueFrom.SecretRef))
 					if err != nil {
 						return nil, err
 					}
-					data[*field.Name] = []byte(AWSSecretValue)
+					value, found = []byte(AWSSecretValue), true
 				}
 
 				if field.ValueFrom.SecretKeyRef != nil {
-					AWSSecretValue, err := secretValueGetter(*field.ValueFrom.SecretKeyRef.Name, iamrole)
+					AWSSecretValue, err := secretValueGetter(ctx, *field.ValueFrom.SecretKeyRef.Name, iamrole, secretKeyRefVersion(field.ValueFrom.SecretKeyRef))
 					if err != nil {
 						return nil, err
 					}
-					AWSSecretValuesMap := map[string]interface{}{}
-					if err := json.Unmarshal([]byte(AWSSecretValue), &AWSSecretValuesMap); err != nil {
-						return nil, err
+					AWSSecretValuesMap, err := decodeJSONSecret(AWSSecretValue)
+					if err != nil {
+						return nil, errors.WithMessagef(err, "secret %s is not a valid JSON", *field.ValueFrom.SecretKeyRef.Name)
+					}
+					keyValue, err := lookupKey(AWSSecretValuesMap, *field.ValueFrom.SecretKeyRef.Key)
+					var notFound *KeyNotFoundError
+					if err == nil {
+						formatted, err := formatValue(keyValue)
+						if err != nil {
+							return nil, errors.WithMessagef(err, "failed formatting key %s of secret %s", *field.ValueFrom.SecretKeyRef.Key, *field.ValueFrom.SecretKeyRef.Name)
+						}
+						value, found = []byte(formatted), true
+					} else if !errors.As(err, &notFound) || !field.ValueFrom.SecretKeyRef.Optional {
+						return nil, errors.WithMessagef(err, "failed reading key %s of secret %s", *field.ValueFrom.SecretKeyRef.Key, *field.ValueFrom.SecretKeyRef.Name)
 					}
-					data[*field.Name] = []byte(fmt.Sprintf("%v", AWSSecretValuesMap[*field.ValueFrom.SecretKeyRef.Key]))
 				}
 
-				if field.ValueFrom.Template != nil {
-					tpl := template.New(cs.Name)
-					tpl = tpl.Funcs(template.FuncMap{
-						"getSecretValue": func(secretID string) (string, error) {
-							return secretValueGetter(secretID, iamrole)
-						},
-						"getSecretValueMap": func(secretID string) (map[string]interface{}, error) {
-							raw, err := secretValueGetter(secretID, iamrole)
-							if err != nil {
-								return nil, fmt.Errorf("failed retrieving value for secret %s", secretID)
-							}
-							var asMap map[string]interface{}
-							if err := json.Unmarshal([]byte(raw), &asMap); err != nil {
-								return nil, fmt.Errorf("secret %s does not contain a valid JSON", secretID)
-							}
-							return asMap, err
-						},
-						"filterByTagKey": secretFilterByTagKey,
-						"base64": func(value interface{}) string {
-							return base64.StdEncoding.EncodeToString([]byte(value.(string)))
-						},
-						"indent": sprig.FuncMap()["indent"],
-					})
-
-					var err error
-					if tpl, err = tpl.Parse(*field.ValueFrom.Template); err != nil {
-						return nil, errors.Wrap(err, "error parsing template from secret")
+				if field.ValueFrom.Template != nil || field.ValueFrom.TemplateRef != nil {
+					rendered, err := render(field.ValueFrom.Template, field.ValueFrom.TemplateRef)
+					if err != nil {
+						return nil, err
 					}
+					value, found = rendered, true
+				}
 
-					buf := new(bytes.Buffer)
-					type templateParams struct {
-						Secrets secretsmanager.Secrets
-					}
-					if err = tpl.Execute(buf, templateParams{Secrets: secrets}); err != nil {
-						return nil, errors.Wrap(err, "error executing template from SyncedSecret")
+				if found {
+					decoded, err := decodeValue(field.ValueFrom.DecodingStrategy, value)
+					if err != nil {
+						return nil, errors.WithMessagef(err, "failed decoding field %s", *field.Name)
 					}
-
-					data[*field.Name] = buf.Bytes()
+					data[*field.Name] = decoded
 				}
 			}
 		}
 	}
+
+	if propagate := cs.Spec.SecretMetadata.PropagateTags; propagate != nil {
+		secretIDs := make([]string, 0, len(readSecrets))
+		for secretID := range readSecrets {
+			secretIDs = append(secretIDs, secretID)
+		}
+		target := annotations
+		if propagate.Target == secretsv1.TagTargetLabels {
+			target = labels
+		}
+		// tags have the lowest precedence
+		for key, value := range propagatedTags(propagate, secretIDs, secrets) {
+			if _, ok := target[key]; !ok {
+				target[key] = value
+			}
+		}
+	}
+
+	secretType := cs.Spec.SecretMetadata.Type
+	if secretType == "" {
+		secretType = corev1.SecretTypeOpaque
+	}
+	if err := validateSecretType(secretType, data); err != nil {
+		return nil, err
+	}
+
+	if len(annotations) > 0 {
+		secretMeta.Annotations = annotations
+	}
+	if len(labels) > 0 {
+		secretMeta.Labels = labels
+	}
+
 	secret := &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
 			Kind:       "Secret",
 		},
 		ObjectMeta: secretMeta,
-		Type:       "Opaque",
+		Type:       secretType,
 		Data:       data,
 	}
 
 	return secret, nil
 }
 
+// iamRole returns the role assumed to read the secrets of a SyncedSecret
+func iamRole(cs secretsv1.SyncedSecret) string {
+	if cs.Spec.AWSAccountID != nil {
+		return fmt.Sprintf("arn:aws:iam::%s:role/secret-syncer", *cs.Spec.AWSAccountID)
+	}
+	if cs.Spec.IAMRole != nil {
+		return *cs.Spec.IAMRole
+	}
+	return ""
+}
+
+// templateFuncs returns the functions available in templated fields, reading secrets with iamrole
+func templateFuncs(secretValueGetter func(string, string, secretsmanager.SecretVersion) (string, error), iamrole string, secretFilterByTagKey func(secretsmanager.Secrets, string) secretsmanager.Secrets) template.FuncMap {
+	funcs := curatedFuncs()
+	for name, f := range map[string]interface{}{
+		"getSecretValue": func(secretID string) (string, error) {
+			return secretValueGetter(secretID, iamrole, secretsmanager.SecretVersion{})
+		},
+		"getSecretValueMap": func(secretID string) (map[string]interface{}, error) {
+			raw, err := secretValueGetter(secretID, iamrole, secretsmanager.SecretVersion{})
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed retrieving value for secret %s", secretID)
+			}
+			var asMap map[string]interface{}
+			if err := json.Unmarshal([]byte(raw), &asMap); err != nil {
+				return nil, fmt.Errorf("secret %s does not contain a valid JSON", secretID)
+			}
+			return asMap, err
+		},
+		"filterByTagKey":     secretFilterByTagKey,
+		"filterByTag":        secretsmanager.FilterByTag,
+		"filterByNamePrefix": secretsmanager.FilterByNamePrefix,
+		"filterByNameRegex":  secretsmanager.FilterByNameRegex,
+		"sortedNames":        secretsmanager.SortedNames,
+		// replaced when the template is executed, declared here so that includes parse
+		"include": func(string, interface{}) (string, error) {
+			return "", errors.New("include is not available")
+		},
+		"base64": func(value interface{}) string {
+			return base64.StdEncoding.EncodeToString([]byte(value.(string)))
+		},
+	} {
+		funcs[name] = f
+	}
+	return funcs
+}
+
+// ParseTemplate parses a templated field without executing it, to report syntax errors and unknown functions
+func ParseTemplate(name, text string) error {
+	if _, err := template.New(name).Funcs(templateFuncs(nil, "", secretsmanager.FilterByTagKey)).Parse(text); err != nil {
+		return &TemplateError{errors.Wrap(err, "error parsing template")}
+	}
+	return nil
+}
+
+// ImmutableSecretName returns the name of the immutable version of a Secret, based on the hash of its data. name is
+// truncated so that the result is still a valid Secret name.
+func ImmutableSecretName(name string, secret *corev1.Secret) string {
+	suffix := "-" + SecretHash(secret)[:10]
+	if maxLength := validation.DNS1123SubdomainMaxLength - len(suffix); len(name) > maxLength {
+		name = strings.TrimRight(name[:maxLength], ".-")
+	}
+	return name + suffix
+}
+
+// MergeK8SSecret returns a copy of existing with the data, labels and annotations of generated written into it
+func MergeK8SSecret(existing, generated *corev1.Secret) *corev1.Secret {
+	merged := existing.DeepCopy()
+	if merged.Data == nil {
+		merged.Data = map[string][]byte{}
+	}
+	for key, value := range generated.Data {
+		merged.Data[key] = value
+	}
+	if len(generated.Labels) > 0 && merged.Labels == nil {
+		merged.Labels = map[string]string{}
+	}
+	for key, value := range generated.Labels {
+		merged.Labels[key] = value
+	}
+	if len(generated.Annotations) > 0 && merged.Annotations == nil {
+		merged.Annotations = map[string]string{}
+	}
+	for key, value := range generated.Annotations {
+		merged.Annotations[key] = value
+	}
+
+	return merged
+}
+
+// secretRefVersion returns the version of the secret selected by a SecretRef
+func secretRefVersion(ref *secretsv1.SecretRef) secretsmanager.SecretVersion {
+	return secretsmanager.SecretVersion{Stage: ref.VersionStage, ID: ref.VersionID}
+}
+
+// secretKeyRefVersion returns the version of the secret selected by a SecretKeyRef
+func secretKeyRefVersion(ref *secretsv1.SecretKeyRef) secretsmanager.SecretVersion {
+	return secretsmanager.SecretVersion{Stage: ref.VersionStage, ID: ref.VersionID}
+}
+
+// validateSecretType ensures data contains the keys required by the Secret type, so that
+// we report a clear error instead of having the API server reject the Secret
+func validateSecretType(secretType corev1.SecretType, data map[string][]byte) error {
+	switch secretType {
+	case corev1.SecretTypeBasicAuth:
+		_, hasUsername := data[corev1.BasicAuthUsernameKey]
+		_, hasPassword := data[corev1.BasicAuthPasswordKey]
+		if !hasUsername && !hasPassword {
+			return fmt.Errorf("secret of type %s requires at least one of the keys %s, %s", secretType, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+		}
+		return nil
+	case corev1.SecretTypeServiceAccountToken:
+		return fmt.Errorf("secret of type %s can not be generated from a SyncedSecret", secretType)
+	}
+
+	missingKeys := []string{}
+	for _, key := range requiredKeysBySecretType[secretType] {
+		if _, ok := data[key]; !ok {
+			missingKeys = append(missingKeys, key)
+		}
+	}
+	if len(missingKeys) > 0 {
+		return fmt.Errorf("secret of type %s is missing required keys: %s", secretType, strings.Join(missingKeys, ", "))
+	}
+
+	// docker configs need to be valid JSON, or the API server will reject the Secret
+	if secretType == corev1.SecretTypeDockerConfigJson || secretType == corev1.SecretTypeDockercfg {
+		for _, key := range requiredKeysBySecretType[secretType] {
+			if !json.Valid(data[key]) {
+				return fmt.Errorf("key %s of secret of type %s is not a valid JSON", key, secretType)
+			}
+		}
+	}
+
+	return nil
+}
+
 func SecretLength(secret *corev1.Secret) int {
 	length := 0
 
@@ -189,3 +475,20 @@ func SecretLength(secret *corev1.Secret) int {
 
 	return length
 }
+
+// SecretHash returns a hash of the data of a Secret, that only changes when its keys or values change
+func SecretHash(secret *corev1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for k := range secret.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%d:%s%d:", len(k), k, len(secret.Data[k]))
+		h.Write(secret.Data[k])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
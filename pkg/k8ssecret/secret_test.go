@@ -1,11 +1,14 @@
 package k8ssecret
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/contentful-labs/kube-secret-syncer/pkg/secretsmanager"
@@ -14,24 +17,26 @@ import (
 	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func _s(A string) *string {
 	return &A
 }
 
-func mockgetSecretValue(string, string) (string, error) {
+func mockgetSecretValue(context.Context, string, string, secretsmanager.SecretVersion) (string, error) {
 	return `{
 		"key1": "value1",
 		"key2": "value2"
 	}`, nil
 }
 
-func mockgetNonJSONSecretValue(string, string) (string, error) {
+func mockgetNonJSONSecretValue(context.Context, string, string, secretsmanager.SecretVersion) (string, error) {
 	return `not a json`, nil
 }
 
-func mockgetDBSecretValue(secretID string, role string) (string, error) {
+func mockgetDBSecretValue(ctx context.Context, secretID string, role string, version secretsmanager.SecretVersion) (string, error) {
 	user := "contentful"
 	if strings.Contains(secretID, "graphapi") {
 		user = "graphapi"
@@ -51,7 +56,29 @@ func mockgetDBSecretValue(secretID string, role string) (string, error) {
 	return string(asJson), nil
 }
 
-func mockFailinggetSecretValue(string, string) (string, error) {
+func mockgetVersionedSecretValue(ctx context.Context, secretID string, role string, version secretsmanager.SecretVersion) (string, error) {
+	if version.Stage == "AWSPREVIOUS" {
+		return `{"password": "previous"}`, nil
+	}
+	if version.ID != "" {
+		return fmt.Sprintf(`{"password": "%s"}`, version.ID), nil
+	}
+	return `{"password": "current"}`, nil
+}
+
+func mockgetNestedSecretValue(context.Context, string, string, secretsmanager.SecretVersion) (string, error) {
+	return `{"port": 1000000, "enabled": true, "db": {"user": "contentful", "hosts": ["a", "b"]}}`, nil
+}
+
+func mockgetBinarySecretValue(context.Context, string, string, secretsmanager.SecretVersion) (string, error) {
+	return "\x00\x01\xfe\xff", nil
+}
+
+func mockgetEncodedSecretValue(context.Context, string, string, secretsmanager.SecretVersion) (string, error) {
+	return `{"cert": "Y2VydGlmaWNhdGU=", "key": "not base64!"}`, nil
+}
+
+func mockFailinggetSecretValue(context.Context, string, string, secretsmanager.SecretVersion) (string, error) {
 	return "", fmt.Errorf("failed getting secret value")
 }
 
@@ -61,7 +88,8 @@ func TestGenerateSecret(t *testing.T) {
 		secretVersion     string
 		err               error
 		cachedSecrets     secretsmanager.Secrets
-		secretValueGetter func(string, string) (string, error)
+		tplContext        TemplateContext
+		secretValueGetter func(context.Context, string, string, secretsmanager.SecretVersion) (string, error)
 	}
 	testCases := []struct {
 		name string
@@ -509,6 +537,72 @@ func TestGenerateSecret(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "it should be able to select secrets by tag value and name, in sorted order",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						Data: []*secretsv1.SecretField{
+							{
+								Name: _s("foo"),
+								ValueFrom: &secretsv1.ValueFrom{
+									Template: _s(`
+{{- $secrets := filterByNameRegex (filterByTag .Secrets "tag1" "true") "^cachedSecret[0-9]$" -}}
+{{- range sortedNames (filterByNamePrefix $secrets "cached") -}}
+  {{- $secretValue := getSecretValueMap . -}}
+  {{- printf "host=%s\n" $secretValue.host -}}
+{{- end -}}
+`),
+								},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				err: nil,
+				cachedSecrets: secretsmanager.Secrets{
+					"cachedSecret1": {
+						Tags: map[string]string{
+							"tag1": "false",
+						},
+					},
+					"cachedSecret2": {
+						Tags: map[string]string{
+							"tag1": "true",
+						},
+					},
+					"cachedSecret3": {
+						Tags: map[string]string{
+							"tag1": "true",
+						},
+					},
+					"cachedSecret10": {
+						Tags: map[string]string{
+							"tag1": "true",
+						},
+					},
+				},
+				secretValueGetter: mockgetDBSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"foo": []byte("host=cachedSecret2-host\nhost=cachedSecret3-host\n"),
+				},
+			},
+		},
 		{
 			name: "AwsSecret should fail if getSecretvalue Fails",
 			have: have{
@@ -575,87 +669,1033 @@ func TestGenerateSecret(t *testing.T) {
 			},
 			want: nil,
 		},
-	}
-
-	for _, test := range testCases {
-		k8sSecret, err := GenerateK8SSecret(test.have.SyncedSecret, test.have.cachedSecrets, test.have.secretValueGetter, secretsmanager.FilterByTagKey, logr.Logger{})
-		if !reflect.DeepEqual(k8sSecret, test.want) {
-			if k8sSecret != nil && k8sSecret.Data != nil {
-				for k, v := range k8sSecret.Data {
-					fmt.Printf("%s: %s\n", k, string(v))
-				}
-			}
-			want, _ := json.MarshalIndent(test.want, "", " ")
-			got, _ := json.MarshalIndent(k8sSecret, "", " ")
-			t.Errorf("Failed: %s\nwanted:\t%s\ngenerated:\t%s \nerror: %s", test.name, want, got, err)
-		}
-	}
-}
-func TestK8SSecretsEqual(t *testing.T) {
-	testEqualCases := []struct {
-		secret1, secret2 corev1.Secret
-	}{
 		{
-			corev1.Secret{
+			name: "it should render SecretTemplates referenced by templateRef or included, with their parameters",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						Data: []*secretsv1.SecretField{
+							{
+								Name: _s("dsn"),
+								ValueFrom: &secretsv1.ValueFrom{
+									TemplateRef: &secretsv1.TemplateRef{Name: "dsn", Parameters: map[string]string{"user": "app", "db": "main"}},
+								},
+							},
+							{
+								Name: _s("host"),
+								ValueFrom: &secretsv1.ValueFrom{
+									Template: _s(`{{ include "host" (dict "Params" (dict "db" "other")) }}`),
+								},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				tplContext: TemplateContext{
+					SecretTemplates: map[string]string{
+						"dsn":  `postgres://{{ .Params.user }}@{{ include "host" . }}/{{ .Params.db }}`,
+						"host": `{{- .Params.db -}}.db.internal`,
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: &corev1.Secret{
 				TypeMeta: metav1.TypeMeta{
-					APIVersion: "v1",
 					Kind:       "Secret",
+					APIVersion: "v1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        "testName",
-					Namespace:   "testNamespace",
-					Annotations: make(map[string]string),
-					Labels:      make(map[string]string),
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
 				},
 				Type: "Opaque",
-				Data: map[string][]byte{},
+				Data: map[string][]byte{
+					"dsn":  []byte("postgres://app@main.db.internal/main"),
+					"host": []byte("other.db.internal"),
+				},
 			},
-			corev1.Secret{
+		},
+		{
+			name: "it should expose the SyncedSecret, its target namespace and the cluster variables to templates",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+						Labels:    map[string]string{"app": "api"},
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						SecretMetadata: secretsv1.SecretMetadata{Namespace: "target-namespace"},
+						Data: []*secretsv1.SecretField{
+							{
+								Name: _s("context"),
+								ValueFrom: &secretsv1.ValueFrom{
+									Template: _s(`{{ .SyncedSecret.Namespace }}/{{ .SyncedSecret.Name }} {{ .SyncedSecret.Labels.app }} {{ .Namespace.Name }} {{ .Namespace.Labels.team }} {{ .Cluster.region }}`),
+								},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				tplContext: TemplateContext{
+					Namespace: &corev1.Namespace{
+						ObjectMeta: metav1.ObjectMeta{Name: "target-namespace", Labels: map[string]string{"team": "a"}},
+					},
+					ClusterVariables: map[string]string{"region": "eu-west-1"},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: &corev1.Secret{
 				TypeMeta: metav1.TypeMeta{
-					APIVersion: "v1",
 					Kind:       "Secret",
+					APIVersion: "v1",
 				},
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        "testName",
-					Namespace:   "testNamespace",
-					Annotations: make(map[string]string),
-					Labels:      make(map[string]string),
+					Name:      "secret-name",
+					Namespace: "target-namespace",
 				},
 				Type: "Opaque",
-				Data: map[string][]byte{},
+				Data: map[string][]byte{
+					"context": []byte("secret-namespace/secret-name api target-namespace a eu-west-1"),
+				},
 			},
 		},
-	}
-
-	for _, testCase := range testEqualCases {
-		if !K8SSecretsEqual(testCase.secret1, testCase.secret2) {
-			t.Errorf("secrets not equal, but should be")
-		}
-	}
-}
-
-func TestSecretlength(t *testing.T) {
-	emptySecret := corev1.Secret{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Secret",
+		{
+			name: "it should fail when a referenced SecretTemplate does not exist",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						Data: []*secretsv1.SecretField{
+							{
+								Name:      _s("dsn"),
+								ValueFrom: &secretsv1.ValueFrom{TemplateRef: &secretsv1.TemplateRef{Name: "dsn"}},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				tplContext:        TemplateContext{SecretTemplates: map[string]string{"host": "db.internal"}},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: nil,
 		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        "testName",
-			Namespace:   "testNamespace",
-			Annotations: make(map[string]string),
-			Labels:      make(map[string]string),
+		{
+			name: "it should fail when SecretTemplates include themselves",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						Data: []*secretsv1.SecretField{
+							{
+								Name:      _s("loop"),
+								ValueFrom: &secretsv1.ValueFrom{TemplateRef: &secretsv1.TemplateRef{Name: "loop"}},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				tplContext:        TemplateContext{SecretTemplates: map[string]string{"loop": `{{ include "loop" . }}`}},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: nil,
 		},
-		Type: "Opaque",
-		Data: map[string][]byte{},
-	}
-	secret := corev1.Secret{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Secret",
+		{
+			name: "it should render the data, labels and annotations of templateFrom, overridden by data and the secret metadata",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						SecretMetadata: secretsv1.SecretMetadata{
+							Labels: map[string]string{"team": "a"},
+						},
+						TemplateFrom: &secretsv1.TemplateFrom{
+							Template: _s(`
+data:
+{{- range sortedNames (filterByTag .Secrets "tag1" "true") }}
+  {{ . }}: {{ (getSecretValueMap .).host | quote }}
+{{- end }}
+  port: 5432
+  cachedSecret3: overridden
+labels:
+  team: b
+  shards: "2"
+`),
+						},
+						Data: []*secretsv1.SecretField{
+							{Name: _s("cachedSecret3"), Value: _s("from data")},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				cachedSecrets: secretsmanager.Secrets{
+					"cachedSecret1": {Tags: map[string]string{"tag1": "false"}},
+					"cachedSecret2": {Tags: map[string]string{"tag1": "true"}},
+					"cachedSecret3": {Tags: map[string]string{"tag1": "true"}},
+				},
+				secretValueGetter: mockgetDBSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+					Labels:    map[string]string{"team": "a", "shards": "2"},
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"cachedSecret2": []byte("cachedSecret2-host"),
+					"cachedSecret3": []byte("from data"),
+					"port":          []byte("5432"),
+				},
+			},
 		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        "testName",
+		{
+			name: "it should fail when templateFrom does not render an object with data, labels and annotations",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						TemplateFrom: &secretsv1.TemplateFrom{Template: _s(`foo: bar`)},
+						IAMRole:      _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: nil,
+		},
+		{
+			name: "it should fail when templateFrom renders an invalid label value",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						TemplateFrom: &secretsv1.TemplateFrom{Template: _s(`labels: {team: "team a"}`)},
+						IAMRole:      _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: nil,
+		},
+		{
+			name: "it should fail when templateFrom renders an invalid label key",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						TemplateFrom: &secretsv1.TemplateFrom{Template: _s(`labels: {"team name": a}`)},
+						IAMRole:      _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: nil,
+		},
+		{
+			name: "it should fail when templateFrom renders an invalid annotation key",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						TemplateFrom: &secretsv1.TemplateFrom{Template: _s(`annotations: {"/owner": a}`)},
+						IAMRole:      _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: nil,
+		},
+		{
+			name: "it should fail when templateFrom renders an invalid data key",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						TemplateFrom: &secretsv1.TemplateFrom{Template: _s(`data: {"db password": a}`)},
+						IAMRole:      _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: nil,
+		},
+		{
+			name: "it should render templated labels and annotations",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						SecretMetadata: secretsv1.SecretMetadata{
+							Labels: map[string]string{
+								"team":   `{{ (index .Secrets "cachedSecret1").Tags.team }}`,
+								"static": "value",
+							},
+							Annotations: map[string]string{
+								"updated-at": `{{ (index .Secrets "cachedSecret1").UpdatedAt.Format "2006-01-02T15:04:05Z07:00" }}`,
+								"host":       `{{ (getSecretValueMap "cachedSecret1").host }}`,
+							},
+							TemplateMetadata: true,
+						},
+						Data: []*secretsv1.SecretField{
+							{Name: _s("foo"), Value: _s("bar")},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				cachedSecrets: secretsmanager.Secrets{
+					"cachedSecret1": {
+						Tags:      map[string]string{"team": "a"},
+						UpdatedAt: time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+					},
+				},
+				secretValueGetter: mockgetDBSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "secret-name",
+					Namespace:   "secret-namespace",
+					Labels:      map[string]string{"team": "a", "static": "value"},
+					Annotations: map[string]string{"updated-at": "2021-03-04T05:06:07Z", "host": "cachedSecret1-host"},
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"foo": []byte("bar"),
+				},
+			},
+		},
+		{
+			name: "it should write label and annotation values as they are unless the metadata is templated",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						SecretMetadata: secretsv1.SecretMetadata{
+							Annotations: map[string]string{"helm.sh/hook-template": `{{ .Values.name }}`},
+						},
+						Data: []*secretsv1.SecretField{
+							{Name: _s("foo"), Value: _s("bar")},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "secret-name",
+					Namespace:   "secret-namespace",
+					Annotations: map[string]string{"helm.sh/hook-template": `{{ .Values.name }}`},
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"foo": []byte("bar"),
+				},
+			},
+		},
+		{
+			name: "it should fail when a templated label renders an invalid label value",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						SecretMetadata: secretsv1.SecretMetadata{
+							Labels:           map[string]string{"host": `{{ getSecretValue "cachedSecret1" }}`},
+							TemplateMetadata: true,
+						},
+						Data: []*secretsv1.SecretField{
+							{Name: _s("foo"), Value: _s("bar")},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: nil,
+		},
+		{
+			name: "it should propagate the tags of the secrets read, below the labels of the secret metadata",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						SecretMetadata: secretsv1.SecretMetadata{
+							Labels: map[string]string{"aws/classification": "public"},
+							PropagateTags: &secretsv1.PropagateTags{
+								Tags:   []string{"owner", "classification", "Cost Centre"},
+								Target: secretsv1.TagTargetLabels,
+								Prefix: "aws",
+							},
+						},
+						Data: []*secretsv1.SecretField{
+							{
+								Name:      _s("password"),
+								ValueFrom: &secretsv1.ValueFrom{SecretKeyRef: &secretsv1.SecretKeyRef{Name: _s("cachedSecret1"), Key: _s("password")}},
+							},
+							{
+								Name:      _s("host"),
+								ValueFrom: &secretsv1.ValueFrom{Template: _s(`{{ (getSecretValueMap "cachedSecret2").host }}`)},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				cachedSecrets: secretsmanager.Secrets{
+					"cachedSecret1": {Tags: map[string]string{"owner": "team-a", "classification": "secret", "Cost Centre": "42"}},
+					"cachedSecret2": {Tags: map[string]string{"owner": "team-a", "Cost Centre": "43"}},
+					"cachedSecret3": {Tags: map[string]string{"owner": "team-b"}},
+				},
+				secretValueGetter: mockgetDBSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+					Labels:    map[string]string{"aws/classification": "public", "aws/owner": "team-a"},
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"password": []byte("cachedSecret1-password"),
+					"host":     []byte("cachedSecret2-host"),
+				},
+			},
+		},
+		{
+			name: "it should generate secrets of the type set in the secret metadata",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						SecretMetadata: secretsv1.SecretMetadata{
+							Type: corev1.SecretTypeTLS,
+						},
+						Data: []*secretsv1.SecretField{
+							{
+								Name:  _s("tls.crt"),
+								Value: _s("cert"),
+							},
+							{
+								Name:  _s("tls.key"),
+								Value: _s("key"),
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				err:               nil,
+				cachedSecrets:     secretsmanager.Secrets{"cachedSecret1": {}, "cachedSecret2": {}},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: map[string][]byte{
+					"tls.crt": []byte("cert"),
+					"tls.key": []byte("key"),
+				},
+			},
+		},
+		{
+			name: "it should fail if a key required by the secret type is missing",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						SecretMetadata: secretsv1.SecretMetadata{
+							Type: corev1.SecretTypeTLS,
+						},
+						Data: []*secretsv1.SecretField{
+							{
+								Name:  _s("tls.crt"),
+								Value: _s("cert"),
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				err:               nil,
+				cachedSecrets:     secretsmanager.Secrets{"cachedSecret1": {}, "cachedSecret2": {}},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: nil,
+		},
+		{
+			name: "it should read the version of the secret selected by the version stage or version ID",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						Data: []*secretsv1.SecretField{
+							{
+								Name: _s("current"),
+								ValueFrom: &secretsv1.ValueFrom{
+									SecretKeyRef: &secretsv1.SecretKeyRef{Name: _s("cf/secret/test"), Key: _s("password")},
+								},
+							},
+							{
+								Name: _s("previous"),
+								ValueFrom: &secretsv1.ValueFrom{
+									SecretKeyRef: &secretsv1.SecretKeyRef{Name: _s("cf/secret/test"), Key: _s("password"), VersionStage: "AWSPREVIOUS"},
+								},
+							},
+							{
+								Name: _s("pinned"),
+								ValueFrom: &secretsv1.ValueFrom{
+									SecretRef: &secretsv1.SecretRef{Name: _s("cf/secret/test"), VersionID: "v1"},
+								},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetVersionedSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"current":  []byte("current"),
+					"previous": []byte("previous"),
+					"pinned":   []byte(`{"password": "v1"}`),
+				},
+			},
+		},
+		{
+			name: "it should fail when a secretKeyRef key does not exist",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						Data: []*secretsv1.SecretField{
+							{
+								Name: _s("missing"),
+								ValueFrom: &secretsv1.ValueFrom{
+									SecretKeyRef: &secretsv1.SecretKeyRef{Name: _s("cf/secret/test"), Key: _s("key3")},
+								},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: nil,
+		},
+		{
+			name: "it should skip optional secretKeyRef keys that do not exist",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						Data: []*secretsv1.SecretField{
+							{
+								Name: _s("present"),
+								ValueFrom: &secretsv1.ValueFrom{
+									SecretKeyRef: &secretsv1.SecretKeyRef{Name: _s("cf/secret/test"), Key: _s("key1"), Optional: true},
+								},
+							},
+							{
+								Name: _s("missing"),
+								ValueFrom: &secretsv1.ValueFrom{
+									SecretKeyRef: &secretsv1.SecretKeyRef{Name: _s("cf/secret/test"), Key: _s("key3"), Optional: true},
+								},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"present": []byte("value1"),
+				},
+			},
+		},
+		{
+			name: "it should not decode the DataFrom value of a missing optional secretKeyRef key",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						DataFrom: &secretsv1.DataFrom{SecretRef: &secretsv1.SecretRef{Name: _s("cf/secret/test")}},
+						Data: []*secretsv1.SecretField{
+							{
+								Name: _s("key1"),
+								ValueFrom: &secretsv1.ValueFrom{
+									SecretKeyRef:     &secretsv1.SecretKeyRef{Name: _s("cf/secret/test"), Key: _s("key3"), Optional: true},
+									DecodingStrategy: secretsv1.DecodingStrategyBase64,
+								},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"key1": []byte("value1"),
+					"key2": []byte("value2"),
+				},
+			},
+		},
+		{
+			name: "it should render nested values of a DataFrom field as JSON",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						DataFrom: &secretsv1.DataFrom{SecretRef: &secretsv1.SecretRef{Name: _s("cf/secret/test")}},
+						IAMRole:  _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetNestedSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"port":    []byte("1000000"),
+					"enabled": []byte("true"),
+					"db":      []byte(`{"hosts":["a","b"],"user":"contentful"}`),
+				},
+			},
+		},
+		{
+			name: "it should flatten nested values of a DataFrom field",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						DataFrom: &secretsv1.DataFrom{SecretRef: &secretsv1.SecretRef{Name: _s("cf/secret/test")}, Flatten: true},
+						IAMRole:  _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetNestedSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"port":     []byte("1000000"),
+					"enabled":  []byte("true"),
+					"db.user":  []byte("contentful"),
+					"db.hosts": []byte(`["a","b"]`),
+				},
+			},
+		},
+		{
+			name: "it should write binary secrets byte-for-byte, and allow templates to read them as base64",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						Data: []*secretsv1.SecretField{
+							{
+								Name: _s("keystore.jks"),
+								ValueFrom: &secretsv1.ValueFrom{
+									SecretRef: &secretsv1.SecretRef{Name: _s("cf/secret/keystore")},
+								},
+							},
+							{
+								Name: _s("keystore.b64"),
+								ValueFrom: &secretsv1.ValueFrom{
+									Template: _s(`{{ getSecretValue "cf/secret/keystore" | base64 }}`),
+								},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetBinarySecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"keystore.jks": {0x00, 0x01, 0xfe, 0xff},
+					"keystore.b64": []byte("AAH+/w=="),
+				},
+			},
+		},
+		{
+			name: "it should decode values with their decoding strategy",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						Data: []*secretsv1.SecretField{
+							{
+								Name: _s("tls.crt"),
+								ValueFrom: &secretsv1.ValueFrom{
+									SecretKeyRef:     &secretsv1.SecretKeyRef{Name: _s("cf/secret/test"), Key: _s("cert")},
+									DecodingStrategy: secretsv1.DecodingStrategyBase64,
+								},
+							},
+							{
+								Name: _s("raw"),
+								ValueFrom: &secretsv1.ValueFrom{
+									SecretKeyRef: &secretsv1.SecretKeyRef{Name: _s("cf/secret/test"), Key: _s("cert")},
+								},
+							},
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetEncodedSecretValue,
+			},
+			want: &corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Secret",
+					APIVersion: "v1",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "secret-name",
+					Namespace: "secret-namespace",
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{
+					"tls.crt": []byte("certificate"),
+					"raw":     []byte("Y2VydGlmaWNhdGU="),
+				},
+			},
+		},
+		{
+			name: "it should fail when a value can not be decoded",
+			have: have{
+				SyncedSecret: secretsv1.SyncedSecret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret-name",
+						Namespace: "secret-namespace",
+					},
+					Spec: secretsv1.SyncedSecretSpec{
+						DataFrom: &secretsv1.DataFrom{
+							SecretRef:        &secretsv1.SecretRef{Name: _s("cf/secret/test")},
+							DecodingStrategy: secretsv1.DecodingStrategyBase64,
+						},
+						IAMRole: _s("iam_role"),
+					},
+				},
+				secretValueGetter: mockgetEncodedSecretValue,
+			},
+			want: nil,
+		},
+	}
+
+	for _, test := range testCases {
+		k8sSecret, err := GenerateK8SSecret(context.Background(), test.have.SyncedSecret, test.have.cachedSecrets, test.have.tplContext, test.have.secretValueGetter, secretsmanager.FilterByTagKey, logr.Logger{})
+		if !reflect.DeepEqual(k8sSecret, test.want) {
+			if k8sSecret != nil && k8sSecret.Data != nil {
+				for k, v := range k8sSecret.Data {
+					fmt.Printf("%s: %s\n", k, string(v))
+				}
+			}
+			want, _ := json.MarshalIndent(test.want, "", " ")
+			got, _ := json.MarshalIndent(k8sSecret, "", " ")
+			t.Errorf("Failed: %s\nwanted:\t%s\ngenerated:\t%s \nerror: %s", test.name, want, got, err)
+		}
+	}
+}
+
+func TestSecretName(t *testing.T) {
+	testCases := []struct {
+		name           string
+		secretMetadata secretsv1.SecretMetadata
+		want           types.NamespacedName
+	}{
+		{
+			name:           "it should default to the name and namespace of the SyncedSecret",
+			secretMetadata: secretsv1.SecretMetadata{},
+			want:           types.NamespacedName{Name: "synced-secret", Namespace: "synced-secret-namespace"},
+		},
+		{
+			name:           "it should use the name set in the secret metadata",
+			secretMetadata: secretsv1.SecretMetadata{Name: "legacy.secret.name"},
+			want:           types.NamespacedName{Name: "legacy.secret.name", Namespace: "synced-secret-namespace"},
+		},
+		{
+			name:           "it should use the name and namespace set in the secret metadata",
+			secretMetadata: secretsv1.SecretMetadata{Name: "secret-name", Namespace: "secret-namespace"},
+			want:           types.NamespacedName{Name: "secret-name", Namespace: "secret-namespace"},
+		},
+	}
+
+	for _, test := range testCases {
+		cs := secretsv1.SyncedSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "synced-secret",
+				Namespace: "synced-secret-namespace",
+			},
+			Spec: secretsv1.SyncedSecretSpec{
+				SecretMetadata: test.secretMetadata,
+			},
+		}
+		if got := SecretName(cs); got != test.want {
+			t.Errorf("%s: wanted %s, got %s", test.name, test.want, got)
+		}
+	}
+}
+
+func TestValidateSecretType(t *testing.T) {
+	testCases := []struct {
+		secretType corev1.SecretType
+		data       map[string][]byte
+		wantErr    bool
+	}{
+		{
+			secretType: corev1.SecretTypeOpaque,
+			data:       map[string][]byte{},
+			wantErr:    false,
+		},
+		{
+			secretType: corev1.SecretTypeTLS,
+			data:       map[string][]byte{"tls.crt": []byte("cert"), "tls.key": []byte("key")},
+			wantErr:    false,
+		},
+		{
+			secretType: corev1.SecretTypeTLS,
+			data:       map[string][]byte{"tls.key": []byte("key")},
+			wantErr:    true,
+		},
+		{
+			secretType: corev1.SecretTypeDockerConfigJson,
+			data:       map[string][]byte{".dockerconfigjson": []byte(`{"auths":{}}`)},
+			wantErr:    false,
+		},
+		{
+			secretType: corev1.SecretTypeDockerConfigJson,
+			data:       map[string][]byte{".dockerconfigjson": []byte("not a json")},
+			wantErr:    true,
+		},
+		{
+			secretType: corev1.SecretTypeBasicAuth,
+			data:       map[string][]byte{"password": []byte("secret")},
+			wantErr:    false,
+		},
+		{
+			secretType: corev1.SecretTypeBasicAuth,
+			data:       map[string][]byte{"token": []byte("secret")},
+			wantErr:    true,
+		},
+		{
+			secretType: corev1.SecretTypeServiceAccountToken,
+			data:       map[string][]byte{},
+			wantErr:    true,
+		},
+	}
+
+	for _, test := range testCases {
+		err := validateSecretType(test.secretType, test.data)
+		if (err != nil) != test.wantErr {
+			t.Errorf("validating secret of type %s with keys %v: wanted error %t, got %v", test.secretType, test.data, test.wantErr, err)
+		}
+	}
+}
+
+func TestK8SSecretsEqual(t *testing.T) {
+	testEqualCases := []struct {
+		secret1, secret2 corev1.Secret
+	}{
+		{
+			corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Secret",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "testName",
+					Namespace:   "testNamespace",
+					Annotations: make(map[string]string),
+					Labels:      make(map[string]string),
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{},
+			},
+			corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "Secret",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "testName",
+					Namespace:   "testNamespace",
+					Annotations: make(map[string]string),
+					Labels:      make(map[string]string),
+				},
+				Type: "Opaque",
+				Data: map[string][]byte{},
+			},
+		},
+	}
+
+	for _, testCase := range testEqualCases {
+		if !K8SSecretsEqual(testCase.secret1, testCase.secret2) {
+			t.Errorf("secrets not equal, but should be")
+		}
+	}
+}
+
+func TestSecretlength(t *testing.T) {
+	emptySecret := corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "testName",
+			Namespace:   "testNamespace",
+			Annotations: make(map[string]string),
+			Labels:      make(map[string]string),
+		},
+		Type: "Opaque",
+		Data: map[string][]byte{},
+	}
+	secret := corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "testName",
 			Namespace:   "testNamespace",
 			Annotations: make(map[string]string),
 			Labels:      make(map[string]string),
@@ -672,3 +1712,112 @@ func TestSecretlength(t *testing.T) {
 		t.Errorf("longer secrets length should be bigger than empty secrets, but it isn't")
 	}
 }
+
+func TestSecretHash(t *testing.T) {
+	secret := &corev1.Secret{Data: map[string][]byte{"user": []byte("contentful"), "password": []byte("alma")}}
+	sameSecret := &corev1.Secret{Data: map[string][]byte{"password": []byte("alma"), "user": []byte("contentful")}}
+	otherSecret := &corev1.Secret{Data: map[string][]byte{"user": []byte("contentfulpassword"), "password": []byte("alma")}}
+
+	if SecretHash(secret) != SecretHash(sameSecret) {
+		t.Errorf("secrets with the same data should have the same hash")
+	}
+	if SecretHash(secret) == SecretHash(otherSecret) {
+		t.Errorf("secrets with different data should have different hashes")
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{name: "static template", template: "static"},
+		{name: "template using functions", template: `{{ getSecretValue "random/aws/secret003" | base64 }}{{ range filterByTagKey .Secrets "tag1" }}{{ .Name }}{{ end }}`},
+		{name: "unterminated action", template: "{{ .Secrets", wantErr: true},
+		{name: "unknown function", template: `{{ getSecret "random/aws/secret003" }}`, wantErr: true},
+	}
+
+	for _, test := range testCases {
+		err := ParseTemplate("test", test.template)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		var templateErr *TemplateError
+		if err != nil && !errors.As(err, &templateErr) {
+			t.Errorf("%s: wanted a TemplateError, got %v", test.name, err)
+		}
+	}
+}
+
+func TestImmutableSecretName(t *testing.T) {
+	secret := &corev1.Secret{Data: map[string][]byte{"user": []byte("contentful"), "password": []byte("alma")}}
+	otherSecret := &corev1.Secret{Data: map[string][]byte{"user": []byte("contentful"), "password": []byte("korte")}}
+
+	name := ImmutableSecretName("demo-service-secret", secret)
+	if name != "demo-service-secret-"+SecretHash(secret)[:10] {
+		t.Errorf("unexpected immutable secret name %s", name)
+	}
+	if name == ImmutableSecretName("demo-service-secret", otherSecret) {
+		t.Errorf("secrets with different data should have different names")
+	}
+
+	longName := strings.Repeat("a", 241) + "." + strings.Repeat("b", 11)
+	name = ImmutableSecretName(longName, secret)
+	if name != strings.Repeat("a", 241)+"-"+SecretHash(secret)[:10] {
+		t.Errorf("unexpected immutable secret name %s for a name of the maximum length", name)
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		t.Errorf("immutable secret name %s is not a valid secret name: %v", name, errs)
+	}
+}
+
+func TestMergeK8SSecret(t *testing.T) {
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "secret-name",
+			Namespace:       "secret-namespace",
+			ResourceVersion: "42",
+			Labels:          map[string]string{"app": "other-tool"},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"kept":        []byte("kept"),
+			"overwritten": []byte("old"),
+		},
+	}
+	generated := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "secret-name",
+			Namespace:   "secret-namespace",
+			Annotations: map[string]string{"synced": "true"},
+		},
+		Data: map[string][]byte{
+			"overwritten": []byte("new"),
+			"added":       []byte("added"),
+		},
+	}
+
+	want := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "secret-name",
+			Namespace:       "secret-namespace",
+			ResourceVersion: "42",
+			Labels:          map[string]string{"app": "other-tool"},
+			Annotations:     map[string]string{"synced": "true"},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"kept":        []byte("kept"),
+			"overwritten": []byte("new"),
+			"added":       []byte("added"),
+		},
+	}
+
+	if got := MergeK8SSecret(existing, generated); !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+	if string(existing.Data["overwritten"]) != "old" {
+		t.Errorf("existing secret should not be modified")
+	}
+}
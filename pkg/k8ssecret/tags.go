@@ -0,0 +1,104 @@
+package k8ssecret
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	"github.com/contentful-labs/kube-secret-syncer/pkg/secretsmanager"
+)
+
+var invalidLabelChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// propagatedTags returns the allowed tags of the secrets read, keyed by their label or annotation key. Secrets can be
+// read by name or by ARN. Tags whose value differs between the secrets are left out, as are tags of secrets missing
+// from the polled secrets.
+func propagatedTags(propagate *secretsv1.PropagateTags, secretIDs []string, secrets secretsmanager.Secrets) map[string]string {
+	values := map[string]string{}
+	conflicts := map[string]bool{}
+
+	sort.Strings(secretIDs)
+	for _, secretID := range secretIDs {
+		meta, ok := polledSecret(secrets, secretID)
+		if !ok {
+			continue
+		}
+		for _, tag := range propagate.Tags {
+			value, ok := meta.Tags[tag]
+			if !ok {
+				continue
+			}
+			if previous, seen := values[tag]; seen && previous != value {
+				conflicts[tag] = true
+			}
+			values[tag] = value
+		}
+	}
+
+	// tags are written in the order they are listed, the first one wins if several are rewritten to the same key
+	propagated := map[string]string{}
+	for _, tag := range propagate.Tags {
+		value, ok := values[tag]
+		if !ok || conflicts[tag] {
+			continue
+		}
+		key := tagKey(propagate.Prefix, tag)
+		if _, exists := propagated[key]; key == "" || exists {
+			continue
+		}
+		if propagate.Target == secretsv1.TagTargetLabels {
+			value = sanitizeLabelValue(value)
+		}
+		propagated[key] = value
+	}
+	return propagated
+}
+
+// polledSecret returns the polled secret secretID refers to. Polled secrets are keyed by name, secretID can also be
+// the full ARN of a secret, or its partial ARN without the random suffix Secrets Manager appends.
+func polledSecret(secrets secretsmanager.Secrets, secretID string) (secretsmanager.PolledSecretMeta, bool) {
+	if meta, ok := secrets[secretID]; ok {
+		return meta, true
+	}
+	if !strings.HasPrefix(secretID, "arn:") {
+		return secretsmanager.PolledSecretMeta{}, false
+	}
+	for _, meta := range secrets {
+		// the random suffix is a dash followed by 6 characters
+		if meta.ARN == secretID || (strings.HasPrefix(meta.ARN, secretID+"-") && len(meta.ARN) == len(secretID)+7) {
+			return meta, true
+		}
+	}
+	return secretsmanager.PolledSecretMeta{}, false
+}
+
+// tagKey rewrites a tag key into a valid label or annotation key, prefixed with prefix if set. It returns an empty
+// string if nothing valid is left of the tag key.
+func tagKey(prefix, tag string) string {
+	name := sanitizeLabelValue(tag)
+	if name == "" {
+		return ""
+	}
+	if prefix != "" {
+		return prefix + "/" + name
+	}
+	return name
+}
+
+// sanitizeLabelValue replaces the characters not allowed in label values with dashes, and trims what can not start
+// or end one
+func sanitizeLabelValue(value string) string {
+	value = invalidLabelChars.ReplaceAllString(value, "-")
+	value = strings.TrimFunc(value, isNotAlphanumeric)
+	if len(value) > validation.LabelValueMaxLength {
+		value = strings.TrimFunc(value[:validation.LabelValueMaxLength], isNotAlphanumeric)
+	}
+	return value
+}
+
+func isNotAlphanumeric(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+}
@@ -0,0 +1,92 @@
+package k8ssecret
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	"github.com/contentful-labs/kube-secret-syncer/pkg/secretsmanager"
+)
+
+func TestTagKey(t *testing.T) {
+	testCases := []struct {
+		name   string
+		prefix string
+		tag    string
+		want   string
+	}{
+		{name: "it should keep valid keys", tag: "owner", want: "owner"},
+		{name: "it should prefix keys", prefix: "aws.contentful.com", tag: "owner", want: "aws.contentful.com/owner"},
+		{name: "it should replace invalid characters", tag: "Cost Centre:id", want: "Cost-Centre-id"},
+		{name: "it should trim what can not start or end a key", tag: "_aws:owner/", want: "aws-owner"},
+		{name: "it should truncate long keys", tag: strings.Repeat("a", 62) + "-b", want: strings.Repeat("a", 62)},
+		{name: "it should skip keys with nothing valid left", tag: "::", want: ""},
+	}
+
+	for _, test := range testCases {
+		if got := tagKey(test.prefix, test.tag); got != test.want {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, got)
+		}
+	}
+}
+
+func TestPropagatedTags(t *testing.T) {
+	secrets := secretsmanager.Secrets{
+		"db": {Tags: map[string]string{"owner": "team a", "Cost Centre": "42", "env": "prod", "ignored": "true"}},
+		"cache": {
+			ARN:  "arn:aws:secretsmanager:us-west-2:123456789012:secret:cache-a1b2c3",
+			Tags: map[string]string{"owner": "team a", "env": "staging"},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		propagate secretsv1.PropagateTags
+		secretIDs []string
+		want      map[string]string
+	}{
+		{
+			name:      "it should copy the allowed tags of the secrets read as annotations",
+			propagate: secretsv1.PropagateTags{Tags: []string{"owner", "Cost Centre"}},
+			secretIDs: []string{"db"},
+			want:      map[string]string{"owner": "team a", "Cost-Centre": "42"},
+		},
+		{
+			name:      "it should rewrite values to valid label values when writing labels",
+			propagate: secretsv1.PropagateTags{Tags: []string{"owner"}, Target: secretsv1.TagTargetLabels, Prefix: "aws.contentful.com"},
+			secretIDs: []string{"db"},
+			want:      map[string]string{"aws.contentful.com/owner": "team-a"},
+		},
+		{
+			name:      "it should skip tags whose value differs between the secrets read",
+			propagate: secretsv1.PropagateTags{Tags: []string{"owner", "env"}},
+			secretIDs: []string{"db", "cache"},
+			want:      map[string]string{"owner": "team a"},
+		},
+		{
+			name:      "it should find the secrets read by ARN",
+			propagate: secretsv1.PropagateTags{Tags: []string{"env"}},
+			secretIDs: []string{"arn:aws:secretsmanager:us-west-2:123456789012:secret:cache-a1b2c3"},
+			want:      map[string]string{"env": "staging"},
+		},
+		{
+			name:      "it should find the secrets read by partial ARN",
+			propagate: secretsv1.PropagateTags{Tags: []string{"env"}},
+			secretIDs: []string{"arn:aws:secretsmanager:us-west-2:123456789012:secret:cache"},
+			want:      map[string]string{"env": "staging"},
+		},
+		{
+			name:      "it should skip secrets that were not polled",
+			propagate: secretsv1.PropagateTags{Tags: []string{"owner"}},
+			secretIDs: []string{"unknown"},
+			want:      map[string]string{},
+		},
+	}
+
+	for _, test := range testCases {
+		if got := propagatedTags(&test.propagate, test.secretIDs, secrets); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package k8ssecret
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig"
+	"sigs.k8s.io/yaml"
+)
+
+// sprigFuncs are the sprig functions available in templates. Functions reading the environment or the network, and
+// functions whose output changes on every call, such as dates, random values, generated keys and the unordered keys
+// or values of a dict, are left out: they would make every sync update the Secret.
+var sprigFuncs = []string{
+	// strings
+	"abbrev", "abbrevboth", "trunc", "trim", "trimAll", "trimall", "trimPrefix", "trimSuffix", "upper", "lower",
+	"title", "untitle", "substr", "nospace", "initials", "swapcase", "snakecase", "camelcase", "kebabcase",
+	"wrap", "wrapWith", "contains", "hasPrefix", "hasSuffix", "quote", "squote", "cat", "replace", "plural", "toString", "toStrings", "split", "splitList", "splitn", "join", "sortAlpha",
+	"regexMatch", "regexFind", "regexFindAll", "regexReplaceAll", "regexReplaceAllLiteral", "regexSplit",
+
+	// defaults and flow control
+	"default", "empty", "coalesce", "ternary", "fail",
+
+	// encoding and hashing
+	"b64enc", "b64dec", "b32enc", "b32dec", "toJson", "toPrettyJson", "sha1sum", "sha256sum", "adler32sum",
+
+	// numbers
+	"atoi", "int", "int64", "float64", "toDecimal", "add", "add1", "sub", "mul", "div", "mod", "max", "min",
+	"biggest", "ceil", "floor", "round",
+
+	// lists and dicts
+	"tuple", "list", "first", "rest", "last", "initial", "reverse", "append", "prepend", "concat", "uniq", "without",
+	"has", "compact", "slice", "dict", "set", "unset", "hasKey", "pluck", "pick", "omit", "merge", "mergeOverwrite",
+	"deepCopy",
+
+	// types
+	"typeOf", "typeIs", "typeIsLike", "kindOf", "kindIs", "deepEqual",
+
+	// paths and URLs
+	"base", "dir", "clean", "ext", "isAbs", "urlParse", "urlJoin",
+
+	// versions
+	"semver", "semverCompare",
+}
+
+// maxGeneratedSize bounds the length of the strings and lists that repeat, indent, nindent, until and untilStep
+// generate from a count, as they would otherwise be allocated before the output size limit of templates applies
+const maxGeneratedSize = 1 << 20
+
+// curatedFuncs returns the sprig functions listed in sprigFuncs, bounded versions of the sprig functions generating
+// strings and lists from a count, and helpers to convert from and to JSON and YAML
+func curatedFuncs() template.FuncMap {
+	all := sprig.TxtFuncMap()
+	funcs := template.FuncMap{}
+	for _, name := range sprigFuncs {
+		funcs[name] = all[name]
+	}
+
+	funcs["repeat"] = repeat
+	funcs["indent"] = indent
+	funcs["nindent"] = nindent
+	funcs["until"] = until
+	funcs["untilStep"] = untilStep
+
+	funcs["fromJson"] = fromJSON
+	funcs["toYaml"] = toYAML
+	funcs["fromYaml"] = fromYAML
+
+	return funcs
+}
+
+func generatedSizeError(name string) error {
+	return &TemplateLimitError{fmt.Sprintf("%s would generate more than %d bytes or elements", name, maxGeneratedSize)}
+}
+
+// repeat is sprig's repeat, failing instead of generating more than maxGeneratedSize bytes
+func repeat(count int, str string) (string, error) {
+	if count < 0 {
+		return "", fmt.Errorf("repeat count %d is negative", count)
+	}
+	if count > 0 && len(str) > maxGeneratedSize/count {
+		return "", generatedSizeError("repeat")
+	}
+	return strings.Repeat(str, count), nil
+}
+
+// indent is sprig's indent, failing instead of adding more than maxGeneratedSize spaces
+func indent(spaces int, v string) (string, error) {
+	if spaces < 0 {
+		return "", fmt.Errorf("indent of %d spaces is negative", spaces)
+	}
+	if lines := strings.Count(v, "\n") + 1; spaces > 0 && lines > maxGeneratedSize/spaces {
+		return "", generatedSizeError("indent")
+	}
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.Replace(v, "\n", "\n"+pad, -1), nil
+}
+
+// nindent is sprig's nindent, failing instead of adding more than maxGeneratedSize spaces
+func nindent(spaces int, v string) (string, error) {
+	indented, err := indent(spaces, v)
+	if err != nil {
+		return "", err
+	}
+	return "\n" + indented, nil
+}
+
+// until is sprig's until, failing instead of generating more than maxGeneratedSize elements
+func until(count int) ([]int, error) {
+	step := 1
+	if count < 0 {
+		step = -1
+	}
+	return untilStep(0, count, step)
+}
+
+// untilStep is sprig's untilStep, failing instead of generating more than maxGeneratedSize elements
+func untilStep(start, stop, step int) ([]int, error) {
+	v := []int{}
+	if step == 0 || (stop < start) != (step < 0) {
+		return v, nil
+	}
+	count := math.Ceil((float64(stop) - float64(start)) / float64(step))
+	if count > maxGeneratedSize {
+		return nil, generatedSizeError("untilStep")
+	}
+	for i := 0; i < int(count); i++ {
+		v = append(v, start+i*step)
+	}
+	return v, nil
+}
+
+// fromJSON decodes a JSON document, e.g. the value of a secret, into maps, lists and scalars
+func fromJSON(value string) (interface{}, error) {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// toYAML encodes a value as YAML, without the trailing newline
+func toYAML(value interface{}) (string, error) {
+	encoded, err := yaml.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(encoded), "\n"), nil
+}
+
+// fromYAML decodes a YAML document into maps, lists and scalars
+func fromYAML(value string) (interface{}, error) {
+	var decoded interface{}
+	if err := yaml.Unmarshal([]byte(value), &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
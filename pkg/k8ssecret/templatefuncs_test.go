@@ -0,0 +1,69 @@
+package k8ssecret
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+)
+
+func TestCuratedFuncs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{name: "toJson", template: `{{ dict "user" "foo" "port" 5432 | toJson }}`, want: `{"port":5432,"user":"foo"}`},
+		{name: "fromJson", template: `{{ (fromJson "{\"db\": {\"user\": \"foo\"}}").db.user }}`, want: "foo"},
+		{name: "invalid JSON", template: `{{ fromJson "not a json" }}`, wantErr: true},
+		{name: "toYaml", template: `{{ dict "db" (dict "user" "foo") | toYaml }}`, want: "db:\n  user: foo"},
+		{name: "fromYaml", template: `{{ (fromYaml "db:\n  port: 5432").db.port }}`, want: "5432"},
+		{name: "b64dec", template: `{{ "aGVsbG8=" | b64dec }}`, want: "hello"},
+		{name: "quote", template: `{{ quote "hello" }}`, want: `"hello"`},
+		{name: "default", template: `{{ "" | default "fallback" }}`, want: "fallback"},
+		{name: "join", template: `{{ list "a" "b" | join "," }}`, want: "a,b"},
+		{name: "trim", template: `{{ trim "  hello  " }}`, want: "hello"},
+		{name: "sha256sum", template: `{{ sha256sum "hello" }}`, want: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{name: "urlquery", template: `{{ urlquery "a b&c" }}`, want: "a+b%26c"},
+		{name: "fail", template: `{{ fail "no value" }}`, wantErr: true},
+		{name: "repeat", template: `{{ repeat 3 "ab" }}`, want: "ababab"},
+		{name: "repeat too long", template: `{{ repeat 1000000000 "ab" }}`, wantErr: true},
+		{name: "indent", template: `{{ "a\nb" | indent 2 }}`, want: "  a\n  b"},
+		{name: "nindent", template: `{{ "a" | nindent 2 }}`, want: "\n  a"},
+		{name: "indent too long", template: `{{ "a" | indent 1000000000 }}`, wantErr: true},
+		{name: "until", template: `{{ until 3 }} {{ until -2 }}`, want: "[0 1 2] [0 -1]"},
+		{name: "untilStep", template: `{{ untilStep 1 10 3 }} {{ untilStep 5 0 -2 }} {{ untilStep 0 5 -1 }}`, want: "[1 4 7] [5 3 1] []"},
+		{name: "until too long", template: `{{ until 1000000000 }}`, wantErr: true},
+		{name: "untilStep too long", template: `{{ untilStep -9223372036854775807 9223372036854775807 1 }}`, wantErr: true},
+	}
+
+	for _, test := range testCases {
+		tpl, err := template.New(test.name).Funcs(curatedFuncs()).Parse(test.template)
+		if err != nil {
+			t.Errorf("%s: failed parsing template: %v", test.name, err)
+			continue
+		}
+		buf := new(bytes.Buffer)
+		err = tpl.Execute(buf, nil)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if err == nil && buf.String() != test.want {
+			t.Errorf("%s: wanted %q, got %q", test.name, test.want, buf.String())
+		}
+	}
+}
+
+func TestCuratedFuncsExcludeNonDeterministicFuncs(t *testing.T) {
+	funcs := curatedFuncs()
+	for _, name := range []string{"env", "expandenv", "getHostByName", "now", "date", "randAlphaNum", "uuidv4", "shuffle", "genPrivateKey", "keys", "values"} {
+		if _, ok := funcs[name]; ok {
+			t.Errorf("function %s should not be available in templates", name)
+		}
+	}
+	for _, name := range sprigFuncs {
+		if funcs[name] == nil {
+			t.Errorf("function %s is not a sprig function", name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package k8ssecret
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	secretsv1 "github.com/contentful-labs/kube-secret-syncer/api/v1"
+	"github.com/contentful-labs/kube-secret-syncer/pkg/secretsmanager"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// maxIncludeDepth bounds nested includes, so that recursive SecretTemplates fail instead of looping forever
+const maxIncludeDepth = 32
+
+// TemplateContext is what templated fields can use besides the secrets
+type TemplateContext struct {
+	// SecretTemplates holds the template of each SecretTemplate by name
+	SecretTemplates map[string]string
+	// Namespace the Kubernetes Secret is written to, if known
+	Namespace *corev1.Namespace
+	// ClusterVariables are set in the configuration of kube-secret-syncer
+	ClusterVariables map[string]string
+	// Limits bounds the rendering of the templates
+	Limits TemplateLimits
+}
+
+// templateData is the data templated fields and SecretTemplates are executed with
+type templateData struct {
+	Secrets      secretsmanager.Secrets
+	Params       map[string]string
+	Namespace    templateObjectMeta
+	SyncedSecret templateObjectMeta
+	Cluster      map[string]string
+}
+
+// templateObjectMeta is the metadata of an object, as exposed to templates
+type templateObjectMeta struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+func newTemplateData(cs secretsv1.SyncedSecret, secrets secretsmanager.Secrets, tplContext TemplateContext) templateData {
+	data := templateData{
+		Secrets: secrets,
+		SyncedSecret: templateObjectMeta{
+			Name:        cs.Name,
+			Namespace:   cs.Namespace,
+			Labels:      cs.Labels,
+			Annotations: cs.Annotations,
+		},
+		Namespace: templateObjectMeta{Name: SecretName(cs).Namespace},
+		Cluster:   tplContext.ClusterVariables,
+	}
+	if tplContext.Namespace != nil {
+		data.Namespace.Labels = tplContext.Namespace.Labels
+		data.Namespace.Annotations = tplContext.Namespace.Annotations
+	}
+	return data
+}
+
+// renderMetadataValue renders a label or annotation value with render if the secret metadata is templated, or returns
+// it as is
+func renderMetadataValue(render func(*string, *secretsv1.TemplateRef) ([]byte, error), metadata secretsv1.SecretMetadata, value string) (string, error) {
+	if !metadata.TemplateMetadata {
+		return value, nil
+	}
+	rendered, err := render(&value, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(rendered), nil
+}
+
+// UsesTemplates returns true if any field, label or annotation of the SyncedSecret is templated
+func UsesTemplates(spec secretsv1.SyncedSecretSpec) bool {
+	if spec.SecretMetadata.TemplateMetadata && len(spec.SecretMetadata.Labels)+len(spec.SecretMetadata.Annotations) > 0 {
+		return true
+	}
+	for _, field := range spec.Data {
+		if field != nil && field.ValueFrom != nil && (field.ValueFrom.Template != nil || field.ValueFrom.TemplateRef != nil) {
+			return true
+		}
+	}
+	return spec.TemplateFrom != nil
+}
+
+// newTemplate returns a template called name, with the SecretTemplates of library associated to it so that they can
+// be executed by name or included. SecretTemplates that fail to parse only fail the templates using them.
+func newTemplate(name string, funcs template.FuncMap, library map[string]string, budget *templateBudget) (*template.Template, map[string]error) {
+	root := template.New(name)
+	parseErrors := map[string]error{}
+
+	depth := 0
+	withInclude := template.FuncMap{}
+	for funcName, f := range funcs {
+		withInclude[funcName] = budget.withDeadline(f)
+	}
+	withInclude["include"] = func(includeName string, data interface{}) (string, error) {
+		if err, ok := parseErrors[includeName]; ok {
+			return "", err
+		}
+		if err := budget.checkDeadline(); err != nil {
+			return "", err
+		}
+		if depth >= maxIncludeDepth {
+			return "", fmt.Errorf("including %s exceeds the maximum depth of %d", includeName, maxIncludeDepth)
+		}
+		depth++
+		defer func() { depth-- }()
+
+		buf := budget.newBuffer()
+		if err := root.ExecuteTemplate(buf, includeName, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	root.Funcs(withInclude)
+
+	names := make([]string, 0, len(library))
+	for libraryName := range library {
+		names = append(names, libraryName)
+	}
+	sort.Strings(names)
+	for _, libraryName := range names {
+		if _, err := root.New(libraryName).Parse(library[libraryName]); err != nil {
+			parseErrors[libraryName] = errors.Wrapf(err, "error parsing SecretTemplate %s", libraryName)
+		}
+	}
+
+	return root, parseErrors
+}
+
+// renderTemplate renders the inline template text, or the SecretTemplate referenced by ref
+func renderTemplate(name string, text *string, ref *secretsv1.TemplateRef, data templateData, library map[string]string, funcs template.FuncMap, budget *templateBudget) ([]byte, error) {
+	tpl, parseErrors := newTemplate(name, funcs, library, budget)
+
+	if ref != nil {
+		name = ref.Name
+		if _, ok := library[name]; !ok {
+			return nil, &TemplateError{fmt.Errorf("SecretTemplate %s not found", name)}
+		}
+		if err, ok := parseErrors[name]; ok {
+			return nil, &TemplateError{err}
+		}
+		data.Params = ref.Parameters
+	} else if _, err := tpl.Parse(*text); err != nil {
+		return nil, &TemplateError{errors.Wrap(err, "error parsing template from secret")}
+	}
+
+	rendered, err := budget.execute(tpl, name, data)
+	if err != nil {
+		return nil, &TemplateError{errors.Wrap(err, "error executing template from SyncedSecret")}
+	}
+	return rendered, nil
+}
+
+// templateFromOutput is the object rendered by a templateFrom
+type templateFromOutput struct {
+	Data        map[string]interface{} `json:"data"`
+	Labels      map[string]string      `json:"labels"`
+	Annotations map[string]string      `json:"annotations"`
+}
+
+// parseTemplateFromOutput decodes the YAML or JSON rendered by a templateFrom, keeping numbers as they were written
+func parseTemplateFromOutput(rendered []byte) (*templateFromOutput, error) {
+	asJSON, err := yaml.YAMLToJSON(rendered)
+	if err != nil {
+		return nil, &TemplateError{errors.Wrap(err, "templateFrom did not render valid YAML or JSON")}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(asJSON))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+	output := &templateFromOutput{}
+	if err := decoder.Decode(output); err != nil {
+		return nil, &TemplateError{errors.Wrap(err, "templateFrom must render an object with the keys data, labels and annotations")}
+	}
+	return output, nil
+}
@@ -0,0 +1,66 @@
+package k8ssecret
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// decodeJSONSecret decodes a JSON secret, keeping numbers as they were written instead of converting them to float64
+func decodeJSONSecret(secretValue string) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(secretValue))
+	decoder.UseNumber()
+
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after JSON object")
+	}
+	return values, nil
+}
+
+// formatValue renders a value decoded by decodeJSONSecret as it should be written in the k8s Secret: strings as is,
+// numbers and booleans as written in the JSON, nested objects and lists as compact JSON, and null as an empty string
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		if v {
+			return "true", nil
+		}
+		return "false", nil
+	default:
+		buf := new(bytes.Buffer)
+		encoder := json.NewEncoder(buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(v); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	}
+}
+
+// flattenValues turns nested objects into keys joined by dots, e.g. {"db": {"user": "foo"}} into {"db.user": "foo"}.
+// Lists are kept as they are.
+func flattenValues(values map[string]interface{}) map[string]interface{} {
+	flattened := map[string]interface{}{}
+	for key, value := range values {
+		nested, ok := value.(map[string]interface{})
+		if !ok || len(nested) == 0 {
+			flattened[key] = value
+			continue
+		}
+		for nestedKey, nestedValue := range flattenValues(nested) {
+			flattened[key+"."+nestedKey] = nestedValue
+		}
+	}
+	return flattened
+}
@@ -0,0 +1,80 @@
+package k8ssecret
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatValue(t *testing.T) {
+	values, err := decodeJSONSecret(`{
+		"string": "<value>",
+		"integer": 1000000,
+		"big": 12345678901234567890,
+		"float": 0.1,
+		"true": true,
+		"false": false,
+		"null": null,
+		"object": {"b": 1, "a": "<x>"},
+		"list": [1, "two", {"three": 3}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{
+		"string":  "<value>",
+		"integer": "1000000",
+		"big":     "12345678901234567890",
+		"float":   "0.1",
+		"true":    "true",
+		"false":   "false",
+		"null":    "",
+		"object":  `{"a":"<x>","b":1}`,
+		"list":    `[1,"two",{"three":3}]`,
+	} {
+		got, err := formatValue(values[key])
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", key, err)
+		}
+		if got != want {
+			t.Errorf("%s: wanted %s, got %s", key, want, got)
+		}
+	}
+}
+
+func TestDecodeJSONSecret(t *testing.T) {
+	for _, secretValue := range []string{`not a json`, `["a", "list"]`, `{"a": 1} {"b": 2}`} {
+		if _, err := decodeJSONSecret(secretValue); err == nil {
+			t.Errorf("expected an error decoding %s", secretValue)
+		}
+	}
+}
+
+func TestFlattenValues(t *testing.T) {
+	values, err := decodeJSONSecret(`{
+		"user": "foo",
+		"db": {"primary": {"host": "primary"}, "port": 5432, "replicas": ["a", "b"]},
+		"empty": {}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	for key, value := range flattenValues(values) {
+		if got[key], err = formatValue(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]string{
+		"user":            "foo",
+		"db.primary.host": "primary",
+		"db.port":         "5432",
+		"db.replicas":     `["a","b"]`,
+		"empty":           "{}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wanted %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,54 @@
+package secretsmanager
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FilterByTag returns the secrets that have the tag tagKey set to tagValue
+func FilterByTag(secrets Secrets, tagKey, tagValue string) Secrets {
+	return filter(secrets, func(_ string, secretMeta PolledSecretMeta) bool {
+		value, ok := secretMeta.Tags[tagKey]
+		return ok && value == tagValue
+	})
+}
+
+// FilterByNamePrefix returns the secrets whose name starts with prefix
+func FilterByNamePrefix(secrets Secrets, prefix string) Secrets {
+	return filter(secrets, func(secretName string, _ PolledSecretMeta) bool {
+		return strings.HasPrefix(secretName, prefix)
+	})
+}
+
+// FilterByNameRegex returns the secrets whose name matches the regular expression pattern. The pattern is not
+// anchored, use ^ and $ to match whole names.
+func FilterByNameRegex(secrets Secrets, pattern string) (Secrets, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return filter(secrets, func(secretName string, _ PolledSecretMeta) bool {
+		return re.MatchString(secretName)
+	}), nil
+}
+
+// SortedNames returns the names of secrets in alphabetical order
+func SortedNames(secrets Secrets) []string {
+	names := make([]string, 0, len(secrets))
+	for secretName := range secrets {
+		names = append(names, secretName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func filter(secrets Secrets, keep func(string, PolledSecretMeta) bool) Secrets {
+	filteredSecrets := Secrets{}
+	for secretName, secretMeta := range secrets {
+		if keep(secretName, secretMeta) {
+			filteredSecrets[secretName] = secretMeta
+		}
+	}
+	return filteredSecrets
+}
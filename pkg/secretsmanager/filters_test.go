@@ -0,0 +1,45 @@
+package secretsmanager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	secrets := Secrets{
+		"team-a/db":      PolledSecretMeta{Tags: map[string]string{"team": "a", "type": "db"}},
+		"team-a/api-key": PolledSecretMeta{Tags: map[string]string{"team": "a"}},
+		"team-b/db":      PolledSecretMeta{Tags: map[string]string{"team": "b", "type": "db"}},
+		"legacy":         PolledSecretMeta{},
+	}
+
+	byRegex := func(pattern string) []string {
+		filtered, err := FilterByNameRegex(secrets, pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return SortedNames(filtered)
+	}
+
+	for _, test := range []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{name: "all secrets", got: SortedNames(secrets), want: []string{"legacy", "team-a/api-key", "team-a/db", "team-b/db"}},
+		{name: "by tag key", got: SortedNames(FilterByTagKey(secrets, "type")), want: []string{"team-a/db", "team-b/db"}},
+		{name: "by tag", got: SortedNames(FilterByTag(secrets, "team", "a")), want: []string{"team-a/api-key", "team-a/db"}},
+		{name: "by missing tag", got: SortedNames(FilterByTag(secrets, "owner", "")), want: []string{}},
+		{name: "by name prefix", got: SortedNames(FilterByNamePrefix(secrets, "team-b/")), want: []string{"team-b/db"}},
+		{name: "by name regex", got: byRegex(`/db$`), want: []string{"team-a/db", "team-b/db"}},
+		{name: "combined", got: SortedNames(FilterByTag(FilterByNamePrefix(secrets, "team-a/"), "type", "db")), want: []string{"team-a/db"}},
+	} {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("%s: wanted %v, got %v", test.name, test.want, test.got)
+		}
+	}
+
+	if _, err := FilterByNameRegex(secrets, "team-("); err == nil {
+		t.Errorf("expected an error for an invalid regular expression")
+	}
+}
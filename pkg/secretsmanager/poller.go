@@ -2,6 +2,7 @@ package secretsmanager
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -21,12 +22,18 @@ type SecretGetter interface {
 type Secrets map[string]PolledSecretMeta
 
 type Poller struct {
-	PolledSecrets     Secrets
+	// polledSecrets holds the secrets listed by the last successful poll, replaced by the poller while reconcilers
+	// read it
+	polledSecrets     atomic.Pointer[Secrets]
 	getSMClient       func(string) (secretsmanageriface.SecretsManagerAPI, error)
 	defaultSearchRole string
 
-	smLastPolledOn           time.Time
+	// smLastPolledOn is the time of the last poll in Unix nanoseconds, written by the poller and read by reconcilers
+	smLastPolledOn atomic.Int64
+	// cacheLock guards the maps by role held in the caches below, shared by the reconcilers of all controllers
+	cacheLock                sync.RWMutex
 	cachedSecretValuesByRole *lru.TwoQueueCache
+	cachedVersionsByRole     *lru.TwoQueueCache
 	cachedSecretsByRole      *lru.TwoQueueCache
 	wg                       sync.WaitGroup
 	errs                     chan<- error
@@ -36,9 +43,11 @@ type Poller struct {
 
 // SecretMeta meta information of a polled secret
 type PolledSecretMeta struct {
-	Tags             map[string]string
-	CurrentVersionID string
-	UpdatedAt        time.Time
+	ARN               string
+	Tags              map[string]string
+	CurrentVersionID  string
+	VersionIDsByStage map[string]string
+	UpdatedAt         time.Time
 }
 
 // New creates a new poller, will send polling or other non critical errors through the errs channel
@@ -53,19 +62,24 @@ func New(interval time.Duration, errs chan error, getSMClient func(string) (secr
 	var err error
 	// init a lru cache that can hold 10000 items (arbit value for now)
 	// this doesn't init the size to value set here, but is only used to figure if eviction is required or not
-	p.cachedSecretValuesByRole, err = lru.New2Q(10000)
-	p.cachedSecretsByRole, err = lru.New2Q(10000)
-	if err != nil {
+	if p.cachedSecretValuesByRole, err = lru.New2Q(10000); err != nil {
+		return nil, err
+	}
+	if p.cachedVersionsByRole, err = lru.New2Q(10000); err != nil {
+		return nil, err
+	}
+	if p.cachedSecretsByRole, err = lru.New2Q(10000); err != nil {
 		return nil, err
 	}
 
 	// poll in sync the first time to ensure that we have a populated cache before reconciler kicks in
-	p.PolledSecrets, err = p.fetchSecrets()
+	polledSecrets, err := p.fetchSecrets()
 	if err != nil {
 		return nil, err
 	}
+	p.polledSecrets.Store(&polledSecrets)
 
-	p.Log.Info("Fetched secrets from AWS after starting", "numberOfSecrets", len(p.PolledSecrets))
+	p.Log.Info("Fetched secrets from AWS after starting", "numberOfSecrets", len(polledSecrets))
 	go func() {
 		p.wg.Add(1)
 		ticker := time.NewTicker(interval)
@@ -77,6 +91,15 @@ func New(interval time.Duration, errs chan error, getSMClient func(string) (secr
 	return p, nil
 }
 
+// PolledSecrets returns the secrets listed by the last successful poll. The poller replaces them on every poll instead
+// of modifying them, so they can be read without a lock.
+func (p *Poller) PolledSecrets() Secrets {
+	if polledSecrets := p.polledSecrets.Load(); polledSecrets != nil {
+		return *polledSecrets
+	}
+	return nil
+}
+
 func (p *Poller) Stop() {
 	p.quit <- true
 	p.wg.Wait()
@@ -91,8 +114,8 @@ func (p *Poller) poll(ticker *time.Ticker) {
 			if err != nil {
 				p.errs <- errors.WithMessagef(err, "failed polling secrets")
 			} else {
-				p.PolledSecrets = polledSecrets
-				p.Log.Info("Fetched secrets from AWS", "numberOfSecres", len(p.PolledSecrets))
+				p.polledSecrets.Store(&polledSecrets)
+				p.Log.Info("Fetched secrets from AWS", "numberOfSecres", len(polledSecrets))
 			}
 
 		case <-p.quit:
@@ -143,24 +166,33 @@ func (p *Poller) fetchSecrets() (Secrets, error) {
 		}
 
 		fetchedSecrets[*secret.Name] = PolledSecretMeta{
-			Tags:             secretTags,
-			CurrentVersionID: versionID,
-			UpdatedAt:        *secret.LastChangedDate,
+			ARN:               aws.StringValue(secret.ARN),
+			Tags:              secretTags,
+			CurrentVersionID:  versionID,
+			VersionIDsByStage: getVersionIDsByStage(secret.SecretVersionsToStages),
+			UpdatedAt:         *secret.LastChangedDate,
 		}
 	}
 
-	p.smLastPolledOn = time.Now().UTC()
+	p.smLastPolledOn.Store(time.Now().UnixNano())
 	return fetchedSecrets, nil
 }
 
 // getCurrentVersion finds the versionid with AWSCURRENT
 func getCurrentVersion(secretVersionToStages map[string][]*string) (string, error) {
+	if uuid, ok := getVersionIDsByStage(secretVersionToStages)[VersionStageCurrent]; ok {
+		return uuid, nil
+	}
+	return "", errors.New("version with stage AWSCURRENT not found")
+}
+
+// getVersionIDsByStage maps every stage of a secret to the versionid it is attached to
+func getVersionIDsByStage(secretVersionToStages map[string][]*string) map[string]string {
+	versionIDsByStage := map[string]string{}
 	for uuid, stages := range secretVersionToStages {
 		for _, stage := range stages {
-			if *stage == "AWSCURRENT" {
-				return uuid, nil
-			}
+			versionIDsByStage[*stage] = uuid
 		}
 	}
-	return "", errors.New("version with stage AWSCURRENT not found")
+	return versionIDsByStage
 }
@@ -90,6 +90,7 @@ func TestFetchSecret(t *testing.T) {
 				Resp: secretsmanager.ListSecretsOutput{
 					SecretList: []*secretsmanager.SecretListEntry{
 						{
+							ARN:             _s("arn:aws:secretsmanager:us-west-2:123456789012:secret:random/aws/secret002-a1b2c3"),
 							Name:            _s("random/aws/secret002"),
 							LastChangedDate: _t(now.AddDate(0, 0, -2)),
 							SecretVersionsToStages: map[string][]*string{
@@ -114,14 +115,17 @@ func TestFetchSecret(t *testing.T) {
 			},
 			want: Secrets{
 				"random/aws/secret002": PolledSecretMeta{
-					CurrentVersionID: "002",
-					UpdatedAt:        now.AddDate(0, 0, -2),
-					Tags:             map[string]string{},
+					ARN:               "arn:aws:secretsmanager:us-west-2:123456789012:secret:random/aws/secret002-a1b2c3",
+					CurrentVersionID:  "002",
+					VersionIDsByStage: map[string]string{"AWSCURRENT": "002"},
+					UpdatedAt:         now.AddDate(0, 0, -2),
+					Tags:              map[string]string{},
 				},
 				"random/aws/secret003": PolledSecretMeta{
-					CurrentVersionID: "005",
-					UpdatedAt:        now.AddDate(0, 0, -3),
-					Tags:             map[string]string{},
+					CurrentVersionID:  "005",
+					VersionIDsByStage: map[string]string{"AWSCURRENT": "005", "AWSPREVIOUS": "003"},
+					UpdatedAt:         now.AddDate(0, 0, -3),
+					Tags:              map[string]string{},
 				},
 			},
 		}, {
@@ -151,9 +155,10 @@ func TestFetchSecret(t *testing.T) {
 			},
 			want: Secrets{
 				"random/aws/secret": PolledSecretMeta{
-					CurrentVersionID: "randomuuid",
-					UpdatedAt:        now.AddDate(0, 0, -2),
-					Tags:             map[string]string{},
+					CurrentVersionID:  "randomuuid",
+					VersionIDsByStage: map[string]string{"AWSCURRENT": "randomuuid"},
+					UpdatedAt:         now.AddDate(0, 0, -2),
+					Tags:              map[string]string{},
 				},
 			},
 		}, {
@@ -282,9 +287,10 @@ func TestPoll(t *testing.T) {
 			},
 			want: Secrets{
 				"random/aws/secret": PolledSecretMeta{
-					CurrentVersionID: "randomuuid",
-					UpdatedAt:        now.AddDate(0, 0, -2),
-					Tags:             map[string]string{},
+					CurrentVersionID:  "randomuuid",
+					VersionIDsByStage: map[string]string{"AWSCURRENT": "randomuuid"},
+					UpdatedAt:         now.AddDate(0, 0, -2),
+					Tags:              map[string]string{},
 				},
 			},
 		},
@@ -316,9 +322,10 @@ func TestPoll(t *testing.T) {
 			},
 			want: Secrets{
 				"random/aws/secret": PolledSecretMeta{
-					CurrentVersionID: "randomuuid",
-					UpdatedAt:        now.AddDate(0, 0, -2),
-					Tags:             map[string]string{},
+					CurrentVersionID:  "randomuuid",
+					VersionIDsByStage: map[string]string{"AWSCURRENT": "randomuuid"},
+					UpdatedAt:         now.AddDate(0, 0, -2),
+					Tags:              map[string]string{},
 				},
 			},
 		},
@@ -341,23 +348,26 @@ func TestPoll(t *testing.T) {
 			p.wg.Done()
 		}()
 
-		nErrs := 0
+		counted := make(chan int)
 		go func() {
+			nErrs := 0
 			for range errs {
 				nErrs = nErrs + 1
 			}
+			counted <- nErrs
 		}()
 
 		time.Sleep(500 * time.Millisecond)
 
 		p.quit <- true
 		p.wg.Wait()
+		nErrs := <-counted
 
 		if nErrs == 0 {
 			t.Errorf("there was no error listing secret - there should have been")
 		}
 
-		if len(p.PolledSecrets) != len(test.want) {
+		if len(p.PolledSecrets()) != len(test.want) {
 			t.Errorf("failing to list secrets seems to have removed the list of PolledSecrets")
 		}
 	}
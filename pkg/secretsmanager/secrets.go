@@ -1,11 +1,23 @@
 package secretsmanager
 
 import (
+	"context"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
 	"github.com/pkg/errors"
 )
 
+// VersionStageCurrent is the stage of the current version of a secret
+const VersionStageCurrent = "AWSCURRENT"
+
+// SecretVersion selects a version of a secret, either by stage or by ID. The zero value selects AWSCURRENT
+type SecretVersion struct {
+	Stage string
+	ID    string
+}
+
 func FilterByTagKey(secrets Secrets, tagKey string) Secrets {
 	filteredSecrets := Secrets{}
 	for secretName, secretMeta := range secrets {
@@ -19,27 +31,34 @@ func FilterByTagKey(secrets Secrets, tagKey string) Secrets {
 	return filteredSecrets
 }
 
-// GetCurrentSecret Returns the secret value for `secretId` with stage `AWSCURRENT`
+// GetCurrentSecret Returns the secret value for `secretId` with stage `AWSCURRENT`. Values stored as SecretBinary
+// are returned byte-for-byte.
 // TODO add a test to ensure this is mocked well including the error
-func (p *Poller) GetSecret(secretID *string, IAMRole string) (string, string, error) {
+func (p *Poller) GetSecret(ctx context.Context, secretID *string, IAMRole string) (string, string, error) {
 	if secretValueOut, ok := p.fetchCurrentSecretCache(secretID, IAMRole); ok {
-		return *secretValueOut.SecretString, *secretValueOut.VersionId, nil
+		return secretPayload(secretID, secretValueOut)
 	}
 
+	return p.getCurrentSecret(ctx, secretID, IAMRole)
+}
+
+// getCurrentSecret retrieves the AWSCURRENT version of `secretId` from Secrets Manager and caches it
+func (p *Poller) getCurrentSecret(ctx context.Context, secretID *string, IAMRole string) (string, string, error) {
 	smClient, err := p.getSMClient(IAMRole)
 	if err != nil {
 		return "", "", err
 	}
 
 	// Not in cache, or new versionID found
-	secretValueOut, err := smClient.GetSecretValue(&secretsmanager.GetSecretValueInput{
+	secretValueOut, err := smClient.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{
 		SecretId:     secretID,
-		VersionStage: aws.String("AWSCURRENT"),
+		VersionStage: aws.String(VersionStageCurrent),
 	})
 	if err != nil {
 		return "", "", errors.WithMessagef(err, "can't find AWSCURRENT version for secretID %s", *secretID)
 	}
 
+	p.cacheLock.Lock()
 	if cachedElem, ok := p.cachedSecretValuesByRole.Get(*secretID); !ok {
 		cachedElem := map[string]secretsmanager.GetSecretValueOutput{
 			IAMRole: *secretValueOut,
@@ -48,16 +67,111 @@ func (p *Poller) GetSecret(secretID *string, IAMRole string) (string, string, er
 	} else {
 		cachedElem.(map[string]secretsmanager.GetSecretValueOutput)[IAMRole] = *secretValueOut
 	}
+	p.cacheLock.Unlock()
+
+	return secretPayload(secretID, secretValueOut)
+}
+
+// GetSecretVersion returns the secret value for `secretId` at the given version. Stages are resolved to a version ID
+// using the polled secrets, and values are cached by version ID, as the value of a version never changes.
+// If maxAge is set and the secrets were polled longer than maxAge ago, stages are read from Secrets Manager instead
+// of the cache. The read from Secrets Manager is cancelled with ctx.
+func (p *Poller) GetSecretVersion(ctx context.Context, secretID *string, IAMRole string, version SecretVersion, maxAge time.Duration) (string, string, error) {
+	if version.Stage != "" && version.ID != "" {
+		return "", "", errors.Errorf("only one of version stage and version ID can be set for secretID %s", *secretID)
+	}
+
+	stale := maxAge > 0 && time.Since(time.Unix(0, p.smLastPolledOn.Load())) > maxAge
+	if version.ID == "" && (version.Stage == "" || version.Stage == VersionStageCurrent) {
+		if stale {
+			return p.getCurrentSecret(ctx, secretID, IAMRole)
+		}
+		return p.GetSecret(ctx, secretID, IAMRole)
+	}
 
-	return *secretValueOut.SecretString, *secretValueOut.VersionId, nil
+	versionID := version.ID
+	if versionID == "" && !stale {
+		if polledSecretMeta, ok := p.PolledSecrets()[*secretID]; ok {
+			versionID = polledSecretMeta.VersionIDsByStage[version.Stage]
+		}
+	}
+
+	if versionID != "" {
+		if secretValueOut, ok := p.fetchSecretVersionCache(secretID, IAMRole, versionID); ok {
+			return secretPayload(secretID, secretValueOut)
+		}
+	}
+
+	smClient, err := p.getSMClient(IAMRole)
+	if err != nil {
+		return "", "", err
+	}
+
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: secretID,
+	}
+	if versionID != "" {
+		input.VersionId = aws.String(versionID)
+	} else {
+		input.VersionStage = aws.String(version.Stage)
+	}
+	secretValueOut, err := smClient.GetSecretValueWithContext(ctx, input)
+	if err != nil {
+		return "", "", errors.WithMessagef(err, "can't find version %s%s for secretID %s", version.Stage, version.ID, *secretID)
+	}
+
+	cacheKey := secretVersionCacheKey(secretID, *secretValueOut.VersionId)
+	p.cacheLock.Lock()
+	if cachedElem, ok := p.cachedVersionsByRole.Get(cacheKey); !ok {
+		cachedElem := map[string]secretsmanager.GetSecretValueOutput{
+			IAMRole: *secretValueOut,
+		}
+		p.cachedVersionsByRole.Add(cacheKey, cachedElem)
+	} else {
+		cachedElem.(map[string]secretsmanager.GetSecretValueOutput)[IAMRole] = *secretValueOut
+	}
+	p.cacheLock.Unlock()
+
+	return secretPayload(secretID, secretValueOut)
+}
+
+// secretPayload returns the value and version of a secret. Secrets stored as SecretBinary are returned byte-for-byte.
+func secretPayload(secretID *string, secretValueOut *secretsmanager.GetSecretValueOutput) (string, string, error) {
+	switch {
+	case secretValueOut.SecretString != nil:
+		return *secretValueOut.SecretString, aws.StringValue(secretValueOut.VersionId), nil
+	case secretValueOut.SecretBinary != nil:
+		return string(secretValueOut.SecretBinary), aws.StringValue(secretValueOut.VersionId), nil
+	default:
+		return "", "", errors.Errorf("secretID %s has neither a SecretString nor a SecretBinary", *secretID)
+	}
+}
+
+func secretVersionCacheKey(secretID *string, versionID string) string {
+	return *secretID + "@" + versionID
+}
+
+func (p *Poller) fetchSecretVersionCache(secretID *string, role string, versionID string) (*secretsmanager.GetSecretValueOutput, bool) {
+	p.cacheLock.RLock()
+	defer p.cacheLock.RUnlock()
+	if cachedElem, ok := p.cachedVersionsByRole.Get(secretVersionCacheKey(secretID, versionID)); ok {
+		secretValuesByRole := cachedElem.(map[string]secretsmanager.GetSecretValueOutput)
+		if secretValueOut, ok := secretValuesByRole[role]; ok {
+			return &secretValueOut, true
+		}
+	}
+
+	return nil, false
 }
 
 func (p *Poller) fetchCurrentSecretCache(secretID *string, role string) (*secretsmanager.GetSecretValueOutput, bool) {
+	p.cacheLock.RLock()
+	defer p.cacheLock.RUnlock()
 	if cachedElem, ok := p.cachedSecretValuesByRole.Get(*secretID); ok {
 		//old secretValueOut := cachedElem.(map[string]*secretsmanager.GetSecretValueOutput)
 		secretValuesByRole := cachedElem.(map[string]secretsmanager.GetSecretValueOutput)
 		if secretValueOut, ok := secretValuesByRole[role]; ok {
-			polledSecretMeta, found := p.PolledSecrets[*secretID]
+			polledSecretMeta, found := p.PolledSecrets()[*secretID]
 			if found && polledSecretMeta.CurrentVersionID == *secretValueOut.VersionId {
 				return &secretValueOut, found
 			}
@@ -85,6 +199,7 @@ func (p *Poller) DescribeSecret(secretID *string, IAMRole string) (secretsmanage
 		return secretsmanager.DescribeSecretOutput{}, errors.WithMessagef(err, "can't find AWSCURRENT version for secretID %s", *secretID)
 	}
 
+	p.cacheLock.Lock()
 	if cachedElem, ok := p.cachedSecretsByRole.Get(*secretID); !ok {
 		cachedElem := map[string]secretsmanager.DescribeSecretOutput{
 			IAMRole: *secretValueOut,
@@ -93,15 +208,18 @@ func (p *Poller) DescribeSecret(secretID *string, IAMRole string) (secretsmanage
 	} else {
 		cachedElem.(map[string]secretsmanager.DescribeSecretOutput)[IAMRole] = *secretValueOut
 	}
+	p.cacheLock.Unlock()
 
 	return *secretValueOut, nil
 }
 
 func (p *Poller) fetchCurrentDescribedSecretCache(secretID *string, role string) (*secretsmanager.DescribeSecretOutput, bool) {
+	p.cacheLock.RLock()
+	defer p.cacheLock.RUnlock()
 	if cachedElem, ok := p.cachedSecretsByRole.Get(*secretID); ok {
 		secretsByRole := cachedElem.(map[string]secretsmanager.DescribeSecretOutput)
 		if secretValueOut, ok := secretsByRole[role]; ok {
-			_, found := p.PolledSecrets[*secretID]
+			_, found := p.PolledSecrets()[*secretID]
 			if found {
 				return &secretValueOut, found
 			}
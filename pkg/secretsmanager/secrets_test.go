@@ -1,12 +1,18 @@
 package secretsmanager
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
 	lru "github.com/hashicorp/golang-lru"
 )
 
@@ -29,14 +35,12 @@ func TestFetchCurrentSecret(t *testing.T) {
 		{
 			name: "when the cache is dirty",
 			have: Have{
-				poller: &Poller{
-					PolledSecrets: Secrets{
-						"cf/secret/test": PolledSecretMeta{
-							CurrentVersionID: "present",
-							UpdatedAt:        time.Now().AddDate(0, 0, -2),
-						},
+				poller: withPolledSecrets(&Poller{}, Secrets{
+					"cf/secret/test": PolledSecretMeta{
+						CurrentVersionID: "present",
+						UpdatedAt:        time.Now().AddDate(0, 0, -2),
 					},
-				},
+				}),
 				secretID: "cf/secret/test",
 				lruElements: map[string]map[string]secretsmanager.GetSecretValueOutput{
 					"cf/secret/test": {
@@ -54,14 +58,12 @@ func TestFetchCurrentSecret(t *testing.T) {
 		{
 			name: "when the cache is valid",
 			have: Have{
-				poller: &Poller{
-					PolledSecrets: Secrets{
-						"cf/secret/test": PolledSecretMeta{
-							CurrentVersionID: "present",
-							UpdatedAt:        time.Now().AddDate(0, 0, -2),
-						},
+				poller: withPolledSecrets(&Poller{}, Secrets{
+					"cf/secret/test": PolledSecretMeta{
+						CurrentVersionID: "present",
+						UpdatedAt:        time.Now().AddDate(0, 0, -2),
 					},
-				},
+				}),
 				secretID: "cf/secret/test",
 				lruElements: map[string]map[string]secretsmanager.GetSecretValueOutput{
 					"cf/secret/test": {
@@ -81,9 +83,7 @@ func TestFetchCurrentSecret(t *testing.T) {
 		{
 			name: "when the polledcache is empty",
 			have: Have{
-				poller: &Poller{
-					PolledSecrets: Secrets{},
-				},
+				poller:   withPolledSecrets(&Poller{}, Secrets{}),
 				secretID: "cf/secret/test",
 				lruElements: map[string]map[string]secretsmanager.GetSecretValueOutput{
 					"cf/secret/test": {
@@ -133,14 +133,12 @@ func TestDescribeSecret(t *testing.T) {
 		{
 			name: "when the cache is dirty",
 			have: Have{
-				poller: &Poller{
-					PolledSecrets: Secrets{
-						"cf/secret/test": PolledSecretMeta{
-							CurrentVersionID: "present",
-							UpdatedAt:        time.Now().AddDate(0, 0, -2),
-						},
+				poller: withPolledSecrets(&Poller{}, Secrets{
+					"cf/secret/test": PolledSecretMeta{
+						CurrentVersionID: "present",
+						UpdatedAt:        time.Now().AddDate(0, 0, -2),
 					},
-				},
+				}),
 				secretID: "cf/secret/test",
 				lruElements: map[string]map[string]secretsmanager.DescribeSecretOutput{
 					"cf/secret/test": {
@@ -159,14 +157,12 @@ func TestDescribeSecret(t *testing.T) {
 		{
 			name: "when the cache is valid",
 			have: Have{
-				poller: &Poller{
-					PolledSecrets: Secrets{
-						"cf/secret/test": PolledSecretMeta{
-							CurrentVersionID: "present",
-							UpdatedAt:        time.Now().AddDate(0, 0, -2),
-						},
+				poller: withPolledSecrets(&Poller{}, Secrets{
+					"cf/secret/test": PolledSecretMeta{
+						CurrentVersionID: "present",
+						UpdatedAt:        time.Now().AddDate(0, 0, -2),
 					},
-				},
+				}),
 				secretID: "cf/secret/test",
 				lruElements: map[string]map[string]secretsmanager.DescribeSecretOutput{
 					"cf/secret/test": {
@@ -187,9 +183,7 @@ func TestDescribeSecret(t *testing.T) {
 		{
 			name: "when the polledcache is empty",
 			have: Have{
-				poller: &Poller{
-					PolledSecrets: Secrets{},
-				},
+				poller:   withPolledSecrets(&Poller{}, Secrets{}),
 				secretID: "cf/secret/test",
 				lruElements: map[string]map[string]secretsmanager.DescribeSecretOutput{
 					"cf/secret/test": {
@@ -220,3 +214,229 @@ func TestDescribeSecret(t *testing.T) {
 	}
 
 }
+
+type mockGetSecretValueClient struct {
+	secretsmanageriface.SecretsManagerAPI
+	versions map[string]string // versionID -> value
+	stages   map[string]string // stage -> versionID
+	calls    []secretsmanager.GetSecretValueInput
+}
+
+func (m *mockGetSecretValueClient) GetSecretValueWithContext(ctx aws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	m.calls = append(m.calls, *input)
+	versionID := aws.StringValue(input.VersionId)
+	if input.VersionStage != nil {
+		versionID = m.stages[*input.VersionStage]
+	}
+	value, ok := m.versions[versionID]
+	if !ok {
+		return nil, awserr.New(secretsmanager.ErrCodeResourceNotFoundException, "version not found", nil)
+	}
+	return &secretsmanager.GetSecretValueOutput{
+		SecretString: aws.String(value),
+		VersionId:    aws.String(versionID),
+	}, nil
+}
+
+func TestGetSecretVersion(t *testing.T) {
+	type Want struct {
+		value     string
+		versionID string
+		calls     int
+		err       bool
+	}
+	for _, test := range []struct {
+		name    string
+		version SecretVersion
+		maxAge  time.Duration
+		cached  bool
+		want    Want
+	}{
+		{
+			name:    "current version",
+			version: SecretVersion{},
+			want:    Want{value: "current", versionID: "v2", calls: 1},
+		},
+		{
+			name:    "current version, from cache",
+			version: SecretVersion{},
+			cached:  true,
+			want:    Want{value: "current", versionID: "v2", calls: 0},
+		},
+		{
+			name:    "current version read from Secrets Manager when the polled secrets are older than maxAge",
+			version: SecretVersion{},
+			maxAge:  time.Nanosecond,
+			cached:  true,
+			want:    Want{value: "current", versionID: "v2", calls: 1},
+		},
+		{
+			name:    "stage resolved through the polled secrets",
+			version: SecretVersion{Stage: "AWSPREVIOUS"},
+			want:    Want{value: "previous", versionID: "v1", calls: 1},
+		},
+		{
+			name:    "stage resolved through the polled secrets, from cache",
+			version: SecretVersion{Stage: "AWSPREVIOUS"},
+			cached:  true,
+			want:    Want{value: "previous", versionID: "v1", calls: 0},
+		},
+		{
+			name:    "stage read from Secrets Manager when the polled secrets are older than maxAge",
+			version: SecretVersion{Stage: "AWSPREVIOUS"},
+			maxAge:  time.Nanosecond,
+			cached:  true,
+			want:    Want{value: "previous", versionID: "v1", calls: 1},
+		},
+		{
+			name:    "version ID",
+			version: SecretVersion{ID: "v1"},
+			want:    Want{value: "previous", versionID: "v1", calls: 1},
+		},
+		{
+			name:    "unknown stage",
+			version: SecretVersion{Stage: "AWSPENDING"},
+			want:    Want{calls: 1, err: true},
+		},
+		{
+			name:    "stage and version ID",
+			version: SecretVersion{Stage: "AWSPREVIOUS", ID: "v1"},
+			want:    Want{calls: 0, err: true},
+		},
+	} {
+		client := &mockGetSecretValueClient{
+			versions: map[string]string{"v1": "previous", "v2": "current"},
+			stages:   map[string]string{"AWSCURRENT": "v2", "AWSPREVIOUS": "v1"},
+		}
+		p := withPolledSecrets(&Poller{
+			getSMClient: func(string) (secretsmanageriface.SecretsManagerAPI, error) {
+				return client, nil
+			},
+		}, Secrets{
+			"cf/secret/test": PolledSecretMeta{
+				CurrentVersionID:  "v2",
+				VersionIDsByStage: map[string]string{"AWSCURRENT": "v2", "AWSPREVIOUS": "v1"},
+			},
+		})
+		p.cachedSecretValuesByRole, _ = lru.New2Q(10)
+		p.cachedVersionsByRole, _ = lru.New2Q(10)
+		if test.cached {
+			p.cachedSecretValuesByRole.Add("cf/secret/test", map[string]secretsmanager.GetSecretValueOutput{
+				"role": {SecretString: aws.String("current"), VersionId: aws.String("v2")},
+			})
+			p.cachedVersionsByRole.Add("cf/secret/test@v1", map[string]secretsmanager.GetSecretValueOutput{
+				"role": {SecretString: aws.String("previous"), VersionId: aws.String("v1")},
+			})
+		}
+
+		p.smLastPolledOn.Store(time.Now().UnixNano())
+		value, versionID, err := p.GetSecretVersion(context.Background(), aws.String("cf/secret/test"), "role", test.version, test.maxAge)
+		if (err != nil) != test.want.err {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if value != test.want.value || versionID != test.want.versionID {
+			t.Errorf("%s: wanted %s/%s, got %s/%s", test.name, test.want.value, test.want.versionID, value, versionID)
+		}
+		if len(client.calls) != test.want.calls {
+			t.Errorf("%s: wanted %d calls to GetSecretValue, got %d", test.name, test.want.calls, len(client.calls))
+		}
+	}
+}
+
+func TestSecretPayload(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		have    secretsmanager.GetSecretValueOutput
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "SecretString",
+			have: secretsmanager.GetSecretValueOutput{SecretString: aws.String(`{"key": "value"}`), VersionId: aws.String("v1")},
+			want: `{"key": "value"}`,
+		},
+		{
+			name: "SecretBinary",
+			have: secretsmanager.GetSecretValueOutput{SecretBinary: []byte{0x00, 0xfe, 0xff}, VersionId: aws.String("v1")},
+			want: "\x00\xfe\xff",
+		},
+		{
+			name:    "no value",
+			have:    secretsmanager.GetSecretValueOutput{VersionId: aws.String("v1")},
+			wantErr: true,
+		},
+	} {
+		got, versionID, err := secretPayload(aws.String("cf/secret/test"), &test.have)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("%s: wanted %q, got %q", test.name, test.want, got)
+		}
+		if !test.wantErr && versionID != "v1" {
+			t.Errorf("%s: wanted version v1, got %s", test.name, versionID)
+		}
+	}
+}
+
+// mockStaticSecretsManagerClient returns the same secret to every call, and can be used concurrently
+type mockStaticSecretsManagerClient struct {
+	secretsmanageriface.SecretsManagerAPI
+}
+
+func (m *mockStaticSecretsManagerClient) GetSecretValueWithContext(ctx aws.Context, input *secretsmanager.GetSecretValueInput, opts ...request.Option) (*secretsmanager.GetSecretValueOutput, error) {
+	versionID := "v2"
+	if input.VersionId != nil {
+		versionID = *input.VersionId
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String("value"), VersionId: aws.String(versionID)}, nil
+}
+
+func (m *mockStaticSecretsManagerClient) DescribeSecret(input *secretsmanager.DescribeSecretInput) (*secretsmanager.DescribeSecretOutput, error) {
+	return &secretsmanager.DescribeSecretOutput{Name: input.SecretId}, nil
+}
+
+func TestConcurrentSecretReads(t *testing.T) {
+	p := withPolledSecrets(&Poller{
+		getSMClient: func(string) (secretsmanageriface.SecretsManagerAPI, error) {
+			return &mockStaticSecretsManagerClient{}, nil
+		},
+	}, Secrets{
+		"cf/secret/test": PolledSecretMeta{
+			CurrentVersionID:  "v2",
+			VersionIDsByStage: map[string]string{"AWSCURRENT": "v2", "AWSPREVIOUS": "v1"},
+		},
+	})
+	p.cachedSecretValuesByRole, _ = lru.New2Q(10)
+	p.cachedVersionsByRole, _ = lru.New2Q(10)
+	p.cachedSecretsByRole, _ = lru.New2Q(10)
+	p.smLastPolledOn.Store(time.Now().UnixNano())
+
+	// reconcilers of different controllers read the same secrets with different roles
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		role := fmt.Sprintf("role%d", i%4)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, _, err := p.GetSecret(context.Background(), aws.String("cf/secret/test"), role); err != nil {
+					t.Error(err)
+				}
+				if _, _, err := p.GetSecretVersion(context.Background(), aws.String("cf/secret/test"), role, SecretVersion{Stage: "AWSPREVIOUS"}, 0); err != nil {
+					t.Error(err)
+				}
+				if _, err := p.DescribeSecret(aws.String("cf/secret/test"), role); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// withPolledSecrets sets the polled secrets of p, as the last poll would
+func withPolledSecrets(p *Poller, secrets Secrets) *Poller {
+	p.polledSecrets.Store(&secrets)
+	return p
+}
@@ -0,0 +1,55 @@
+package targetnamespacevalidator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/contentful-labs/kube-secret-syncer/pkg/k8snamespace"
+	"github.com/pkg/errors"
+)
+
+type TargetNamespaceValidator struct {
+	nsCache        k8snamespace.NamespaceGetter
+	annotationName string
+}
+
+func NewTargetNamespaceValidator(nsCache k8snamespace.NamespaceGetter, annotationName string) *TargetNamespaceValidator {
+	return &TargetNamespaceValidator{
+		nsCache:        nsCache,
+		annotationName: annotationName,
+	}
+}
+
+// AllowsSourceNamespace verifies that SyncedSecrets in sourceNamespace are allowed to write Secrets
+// into targetNamespace. The target namespace needs to opt in by listing the source namespace in its annotation.
+func (tv *TargetNamespaceValidator) AllowsSourceNamespace(targetNamespace, sourceNamespace string) (bool, error) {
+	if targetNamespace == sourceNamespace {
+		return true, nil
+	}
+
+	ns, err := tv.nsCache.Get(targetNamespace)
+	if err != nil {
+		return false, err
+	}
+	if ns == nil {
+		return false, fmt.Errorf("namespace %s not found", targetNamespace)
+	}
+
+	annotation, annotationFound := ns.Annotations[tv.annotationName]
+	if !annotationFound {
+		return false, nil
+	}
+
+	var allowedNamespaces []string
+	if err := json.Unmarshal([]byte(annotation), &allowedNamespaces); err != nil {
+		return false, errors.WithMessagef(err, "failed parsing annotation %s of namespace %s", tv.annotationName, targetNamespace)
+	}
+
+	for _, allowedNamespace := range allowedNamespaces {
+		if allowedNamespace == sourceNamespace {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
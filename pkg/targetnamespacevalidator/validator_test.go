@@ -0,0 +1,79 @@
+package targetnamespacevalidator
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+type mockNSGetter struct {
+	annotations map[string]string
+}
+
+func (m *mockNSGetter) Get(string) (*v1.Namespace, error) {
+	ns := &v1.Namespace{}
+	ns.Annotations = m.annotations
+
+	return ns, nil
+}
+
+func TestAllowsSourceNamespace(t *testing.T) {
+	const annotationName = "secrets.contentful.com/allowed-source-namespaces"
+
+	testCases := []struct {
+		name            string
+		targetNamespace string
+		sourceNamespace string
+		annotations     map[string]string
+		expectAllowed   bool
+		expectErr       bool
+	}{
+		{
+			name:            "secrets can always be written to the namespace of the SyncedSecret",
+			targetNamespace: "ns1",
+			sourceNamespace: "ns1",
+			annotations:     map[string]string{},
+			expectAllowed:   true,
+		},
+		{
+			name:            "target namespace has no annotation",
+			targetNamespace: "ns1",
+			sourceNamespace: "ns2",
+			annotations:     map[string]string{},
+			expectAllowed:   false,
+		},
+		{
+			name:            "target namespace allows the source namespace",
+			targetNamespace: "ns1",
+			sourceNamespace: "ns2",
+			annotations:     map[string]string{annotationName: `["ns3", "ns2"]`},
+			expectAllowed:   true,
+		},
+		{
+			name:            "target namespace does not allow the source namespace",
+			targetNamespace: "ns1",
+			sourceNamespace: "ns2",
+			annotations:     map[string]string{annotationName: `["ns3"]`},
+			expectAllowed:   false,
+		},
+		{
+			name:            "target namespace has an invalid annotation",
+			targetNamespace: "ns1",
+			sourceNamespace: "ns2",
+			annotations:     map[string]string{annotationName: `ns2`},
+			expectAllowed:   false,
+			expectErr:       true,
+		},
+	}
+
+	for _, test := range testCases {
+		tv := NewTargetNamespaceValidator(&mockNSGetter{annotations: test.annotations}, annotationName)
+		allowed, err := tv.AllowsSourceNamespace(test.targetNamespace, test.sourceNamespace)
+		if allowed != test.expectAllowed {
+			t.Errorf("%s: expected allowed to be %t, got %t", test.name, test.expectAllowed, allowed)
+		}
+		if (err != nil) != test.expectErr {
+			t.Errorf("%s: unexpected error value %v", test.name, err)
+		}
+	}
+}